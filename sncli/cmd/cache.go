@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sncli/internal/output"
+	"sncli/internal/snow"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect or clear the metadata cache",
+	Long: `Table and dictionary metadata fetched by schema and query explain is
+cached under ~/.sncli/cache/<instance>/<user>, since users see the records
+their ACLs allow. Entries are reused for a day, then
+revalidated by fetching only records with a newer sys_updated_on.
+
+Use --no-cache to bypass the cache for one command, or --refresh to fetch
+everything again and replace the cached entries.`,
+}
+
+var cacheAll bool
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show cache statistics for the active profile's user and instance",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cache, err := profileCache()
+		if err != nil {
+			return err
+		}
+		stats, err := cache.Stats()
+		if err != nil {
+			return err
+		}
+
+		timestamp := func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Local().Format("2006-01-02 15:04:05")
+		}
+		return out.Print(output.Object(stats,
+			[]string{"Directory", "Entries", "Expired", "Size", "Oldest", "Newest"},
+			[]string{stats.Dir, strconv.Itoa(stats.Entries), strconv.Itoa(stats.Expired),
+				fmt.Sprintf("%.1f KiB", float64(stats.Bytes)/1024), timestamp(stats.Oldest), timestamp(stats.Newest)}))
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove cached metadata for the active profile's user and instance",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cacheAll {
+			root, err := snow.CacheRoot()
+			if err != nil {
+				return err
+			}
+			if err := os.RemoveAll(root); err != nil {
+				return fmt.Errorf("failed to clear cache: %v", err)
+			}
+			out.Success("✓ Cleared the cache of every instance")
+			return nil
+		}
+
+		cache, err := profileCache()
+		if err != nil {
+			return err
+		}
+		if err := cache.Clear(); err != nil {
+			return err
+		}
+		out.Success("✓ Cleared %s", cache.Dir)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatsCmd, cacheClearCmd)
+	cacheClearCmd.Flags().BoolVar(&cacheAll, "all", false, "Clear the cache of every instance")
+}
+
+// profileCache returns the cache of the selected profile's user and
+// instance. It reads neither secrets, so no passphrase is needed.
+func profileCache() (*snow.Cache, error) {
+	cfg := &snow.Config{Instance: os.Getenv(snow.InstanceEnv)}
+	if cfg.Instance == "" {
+		file, err := snow.LoadConfigFile()
+		if err != nil {
+			return nil, err
+		}
+		name := file.Resolve(profile)
+		stored, ok := file.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("profile %q not found - run 'connect --profile %s' or 'profile list'", name, name)
+		}
+		*cfg = *stored
+	}
+	// The same overrides as ReadConfig
+	if v := os.Getenv(snow.UsernameEnv); v != "" {
+		cfg.Username = v
+	}
+	if v := os.Getenv(snow.ClientIDEnv); v != "" {
+		cfg.ClientID = v
+	}
+	baseURL, err := snow.NormalizeInstanceURL(cfg.Instance)
+	if err != nil {
+		return nil, err
+	}
+	return snow.NewCache(baseURL, cfg.CacheUser())
+}
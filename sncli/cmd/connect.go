@@ -1,94 +1,269 @@
-	package cmd
-
-	      import (
-	              "fmt"
-	              "strings"
-	              "time"
-
-	              "github.com/briandowns/spinner"
-	              "github.com/charmbracelet/lipgloss"
-	              "github.com/spf13/cobra"
-	              "sncli/internal/snow"
-	              "sncli/internal/tui"
-	      )
-
-
-	var (
-	        successStyle = lipgloss.NewStyle().
-	                Foreground(lipgloss.Color("42")).
-	                Bold(true)
-	        errorStyle = lipgloss.NewStyle().
-	                Foreground(lipgloss.Color("196")).
-	                Bold(true)
-	        infoStyle = lipgloss.NewStyle().
-	                Foreground(lipgloss.Color("87"))
-	)
-
-	var connectCmd = &cobra.Command{
-	  Use:   "connect",
-	  Short: "Connect to a ServiceNow instance",
-			Run: func(cmd *cobra.Command, args []string) {
-			        model := tui.InitialModel()
-			        program := tui.CreateProgram(model)
-
-			        // Run the TUI and get the result
-			        result, err := program.Run()
-			        if err != nil {
-			                fmt.Println(errorStyle.Render("✗ Error running program:"), err)
-			                return
-			        }
-
-			        loginModel, ok := result.(tui.LoginModel)
-			        if !ok {
-			                fmt.Println(errorStyle.Render("✗ Invalid login model"))
-			                return
-			        }
-
-			        // Start spinner
-			        s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-			        s.Prefix = "  "
-			        s.Suffix = " Connecting to ServiceNow instance..."
-			        s.Start()
-
-											// Process instance name and construct URL
-											instanceName := loginModel.InstanceURL
-											// Remove any domain suffix if present
-											if strings.Contains(instanceName, ".") {
-											    instanceName = strings.Split(instanceName, ".")[0]
-											}
-											// Construct the full ServiceNow URL
-											instanceURL := fmt.Sprintf("https://%s.service-now.com", instanceName)
-
-			        // Simulate API call with delay
-
-																									// Create snow client
-																									client, err := snow.NewClient(instanceName, loginModel.Username, loginModel.Password)
-																									if err != nil {
-																									    s.Stop()
-																									    fmt.Println(errorStyle.Render("\n✗ Failed to create client:"), err)
-																									    return
-																									}
-
-											              // Test connection and authenticate
-											              user, err := client.Authenticate()
-											              s.Stop()
-											              if err != nil {
-											                      fmt.Println(errorStyle.Render("\n✗ Authentication failed:"), err)
-											                      return
-											              }
-
-											              // Save credentials after successful authentication
-											              if err := client.SaveConfig(); err != nil {
-											                      fmt.Println(errorStyle.Render("\n✗ Failed to save credentials:"), err)
-											                      return
-											              }
-
-											              // Show success message
-											              fmt.Println(successStyle.Render("\n✓ Successfully connected to ServiceNow!"))
-											              fmt.Printf(infoStyle.Render("\nInstance: %s\nUser: %s\n"), instanceURL, loginModel.Username)
-											              if user != nil {
-											                      fmt.Printf(infoStyle.Render("Name: %s\nEmail: %s\n"), user.Name, user.Email)
-											              }
-			},
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"sncli/internal/output"
+	"sncli/internal/snow"
+	"sncli/internal/tui"
+)
+
+var connectCmd = &cobra.Command{
+	Use:   "connect",
+	Short: "Connect to a ServiceNow instance",
+	Long: `Connect to a ServiceNow instance and save the credentials to a profile.
+
+Without enough flags or environment variables to log in, connect opens an
+interactive login form. Supplying the instance and credentials skips the
+form, for use in CI:
+
+  echo "$PASSWORD" | sncli connect --instance dev12345 --username admin --password-stdin
+
+Flags fall back to SNCLI_INSTANCE, SNCLI_USERNAME, SNCLI_PASSWORD,
+SNCLI_AUTH_METHOD, SNCLI_CLIENT_ID and SNCLI_CLIENT_SECRET. Saved secrets
+are encrypted with the passphrase from SNCLI_PASSPHRASE or a prompt.`,
+	Args: cobra.NoArgs,
+	RunE: runConnect,
+}
+
+var (
+	authMethod    string
+	username      string
+	passwordStdin bool
+)
+
+func init() {
+	connectCmd.Flags().StringVar(&authMethod, "auth", "", "Auth method (basic or oauth)")
+	connectCmd.Flags().StringVar(&username, "username", "", "ServiceNow username")
+	connectCmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "Read the password from stdin")
+}
+
+// credentials are the login details gathered from flags, the environment or
+// the login form.
+type credentials struct {
+	Instance     string
+	Auth         snow.AuthMethod
+	Username     string
+	Password     string
+	ClientID     string
+	ClientSecret string
+}
+
+// complete reports whether c has everything needed to log in.
+func (c credentials) complete() bool {
+	if c.Instance == "" || c.Username == "" || c.Password == "" {
+		return false
+	}
+	return c.Auth != snow.AuthOAuth || c.ClientID != ""
+}
+
+// flagOrEnv returns the flag value, or the environment variable when the
+// flag is empty.
+func flagOrEnv(value, env string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
+
+// credentialsFromFlags gathers login details from flags and environment.
+func credentialsFromFlags() (credentials, error) {
+	method, err := snow.ParseAuthMethod(flagOrEnv(authMethod, snow.AuthMethodEnv))
+	if err != nil {
+		return credentials{}, usageError(err)
+	}
+	creds := credentials{
+		Instance:     flagOrEnv(instance, snow.InstanceEnv),
+		Auth:         method,
+		Username:     flagOrEnv(username, snow.UsernameEnv),
+		Password:     os.Getenv(snow.PasswordEnv),
+		ClientID:     flagOrEnv(clientID, snow.ClientIDEnv),
+		ClientSecret: flagOrEnv(clientSecret, snow.ClientSecretEnv),
+	}
+	if passwordStdin {
+		if creds.Password, err = readStdinLine(); err != nil {
+			return credentials{}, usageError(err)
+		}
+	}
+	return creds, nil
+}
+
+// readStdinLine reads a single secret line from stdin.
+func readStdinLine() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password from stdin: %v", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("no password on stdin")
+	}
+	return line, nil
+}
+
+func runConnect(cmd *cobra.Command, args []string) error {
+	if webLogin {
+		return runWebLogin(cmd)
 	}
 
+	creds, err := credentialsFromFlags()
+	if err != nil {
+		return fail("Invalid input:", err)
+	}
+
+	if !creds.complete() {
+		if passwordStdin || !term.IsTerminal(int(os.Stdin.Fd())) {
+			return failWith(exitUsage, "Missing credentials:", errors.New("need an instance, username and password (and a client ID for oauth)"))
+		}
+		if creds, err = credentialsFromForm(creds.Auth); err != nil {
+			return err
+		}
+		if creds == (credentials{}) {
+			return nil
+		}
+	}
+
+	return connect(cmd, creds)
+}
+
+// credentialsFromForm runs the interactive login form. It returns empty
+// credentials if the user cancels.
+func credentialsFromForm(method snow.AuthMethod) (credentials, error) {
+	model := tui.InitialModel()
+	model.AuthMethod = method
+	program := tui.CreateProgram(model)
+
+	// Run the TUI and get the result
+	result, err := program.Run()
+	if err != nil {
+		return credentials{}, fail("Error running program:", err)
+	}
+
+	loginModel, ok := result.(tui.LoginModel)
+	if !ok {
+		return credentials{}, fail("Invalid login model", errors.New("unexpected model type"))
+	}
+	if !loginModel.Submitted {
+		return credentials{}, nil
+	}
+	return credentials{
+		Instance:     loginModel.InstanceURL,
+		Auth:         loginModel.AuthMethod,
+		Username:     loginModel.Username,
+		Password:     loginModel.Password,
+		ClientID:     loginModel.ClientID,
+		ClientSecret: loginModel.ClientSecret,
+	}, nil
+}
+
+// connect authenticates with creds and saves them to the profile.
+func connect(cmd *cobra.Command, creds credentials) error {
+	// Only animate when a person is watching, and keep stdout for results
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithWriter(os.Stderr))
+	s.Prefix = "  "
+	s.Suffix = " Connecting to ServiceNow instance..."
+	if output.IsTerminal(os.Stderr) {
+		s.Start()
+	}
+
+	// Normalize the instance name, host or URL into a base URL
+	instanceURL, err := snow.NormalizeInstanceURL(creds.Instance)
+	if err != nil {
+		s.Stop()
+		return failWith(exitUsage, "\nInvalid instance:", err)
+	}
+
+	// Create snow client
+	client, err := newLoginClient(instanceURL, creds)
+	if err != nil {
+		s.Stop()
+		return failWith(exitUsage, "\nFailed to create client:", err)
+	}
+	configureClient(client)
+
+	if client.Auth == snow.AuthOAuth {
+		if err := client.PasswordGrant(cmd.Context()); err != nil {
+			s.Stop()
+			return authFailure("\nFailed to obtain OAuth token:", err)
+		}
+	}
+
+	// Test connection and authenticate
+	user, err := client.Authenticate(cmd.Context())
+	s.Stop()
+	if err != nil {
+		return authFailure("\nAuthentication failed:", err)
+	}
+
+	// Save credentials after successful authentication
+	if err := client.SaveConfig(profile); err != nil {
+		return fail("\nFailed to save credentials:", err)
+	}
+
+	return printConnected(client, user)
+}
+
+// connection is the result printed by connect.
+type connection struct {
+	Profile  string `json:"profile"`
+	Instance string `json:"instance"`
+	User     string `json:"user"`
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+}
+
+// printConnected reports a successful login.
+func printConnected(client *snow.Client, user *snow.UserInfo) error {
+	file, err := snow.LoadConfigFile()
+	if err != nil {
+		return err
+	}
+	result := connection{
+		Profile:  file.Resolve(profile),
+		Instance: client.BaseURL,
+		User:     client.Username,
+	}
+	if user != nil {
+		result.Name = user.Name
+		result.Email = user.Email
+	}
+
+	out.Success("\n✓ Successfully connected to ServiceNow!\n")
+	return out.Print(output.Object(result,
+		[]string{"Profile", "Instance", "User", "Name", "Email"},
+		[]string{result.Profile, result.Instance, result.User, result.Name, result.Email}))
+}
+
+// authFailure reports a failed login, exiting with exitAuth when the
+// instance rejected the credentials rather than failing to answer.
+func authFailure(msg string, err error) error {
+	var authErr *snow.AuthError
+	if errors.As(err, &authErr) && authErr.Status < 500 && authErr.Status != http.StatusTooManyRequests {
+		return failWith(exitAuth, msg, err)
+	}
+	return fail(msg, err)
+}
+
+// newLoginClient creates a client for the given login details.
+func newLoginClient(instanceURL string, c credentials) (*snow.Client, error) {
+	if c.Auth != snow.AuthOAuth {
+		return snow.NewClient(instanceURL, c.Username, c.Password)
+	}
+	client, err := snow.NewOAuthClient(instanceURL, c.ClientID, c.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	client.Username = c.Username
+	client.Password = c.Password
+	return client, nil
+}
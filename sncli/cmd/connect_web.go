@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+
+	"github.com/spf13/cobra"
+	"sncli/internal/snow"
+)
+
+var (
+	webLogin     bool
+	instance     string
+	clientID     string
+	clientSecret string
+	redirectPort int
+)
+
+func init() {
+	connectCmd.Flags().BoolVar(&webLogin, "web", false, "Sign in through the browser (OAuth authorization code with PKCE)")
+	connectCmd.Flags().StringVar(&instance, "instance", "", "ServiceNow instance name, host or URL")
+	connectCmd.Flags().StringVar(&clientID, "client-id", "", "OAuth client ID")
+	connectCmd.Flags().StringVar(&clientSecret, "client-secret", "", "OAuth client secret (omit for public clients)")
+	connectCmd.Flags().IntVar(&redirectPort, "redirect-port", 8765, "Loopback port of the OAuth redirect URL (http://127.0.0.1:<port>/callback)")
+}
+
+// runWebLogin signs in through the browser and saves the resulting token.
+func runWebLogin(cmd *cobra.Command) error {
+	instanceURL := flagOrEnv(instance, snow.InstanceEnv)
+	id := flagOrEnv(clientID, snow.ClientIDEnv)
+	if instanceURL == "" || id == "" {
+		return failWith(exitUsage, "Missing input:", errors.New("--web requires --instance and --client-id"))
+	}
+
+	client, err := snow.NewOAuthClient(instanceURL, id, flagOrEnv(clientSecret, snow.ClientSecretEnv))
+	if err != nil {
+		return failWith(exitUsage, "Failed to create client:", err)
+	}
+	configureClient(client)
+
+	err = client.WebLogin(cmd.Context(), snow.WebLoginOptions{
+		Port: redirectPort,
+		OpenBrowser: func(authURL string) error {
+			out.Info("Opening your browser to sign in. If it does not open, visit:")
+			out.Info("%s", authURL)
+			if err := openBrowser(authURL); err != nil {
+				out.Info("(could not open browser automatically)")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		return authFailure("\nBrowser sign-in failed:", err)
+	}
+
+	user, err := client.Authenticate(cmd.Context())
+	if err != nil {
+		return authFailure("\nAuthentication failed:", err)
+	}
+
+	if err := client.SaveConfig(profile); err != nil {
+		return fail("\nFailed to save credentials:", err)
+	}
+
+	return printConnected(client, user)
+}
+
+// openBrowser opens url in the user's default browser.
+func openBrowser(url string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", url)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		c = exec.Command("xdg-open", url)
+	}
+	return c.Start()
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sncli/internal/output"
+	"sncli/internal/snow"
+)
+
+var schemaProfileCmd = &cobra.Command{
+	Use:   "profile <table>",
+	Short: "Profile the data of a single table",
+	Long: `Profile the records of a table through the Aggregate API: its record
+count and, for every field it has or inherits, how many records fill it.
+Choice, reference and boolean fields also get their number of distinct
+values and most frequent values; date and numeric fields their range.
+
+Counts cover the records of tables extending the table too, as the
+instance reports them.`,
+	Example: `  sncli schema profile incident
+  sncli schema profile x_app_order --output json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkSchemaFlags(); err != nil {
+			return err
+		}
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		applySchemaFlags(client)
+
+		table, err := client.GetTable(cmd.Context(), args[0], true)
+		if err != nil {
+			return err
+		}
+		tables := []snow.Table{table}
+		if err := client.ProfileTables(cmd.Context(), tables, true); err != nil {
+			return fmt.Errorf("failed to profile %s: %w", table.Name, err)
+		}
+		profile := tables[0].Profile
+
+		types := map[string]string{}
+		for _, f := range table.Fields {
+			types[f.Name] = f.Type
+		}
+		result := output.Result{
+			Value:   tableProfile{Table: table.Name, DataProfile: profile},
+			Columns: []string{"Field", "Type", "Filled", "Fill Rate", "Distinct", "Min", "Max", "Top Values"},
+		}
+		for _, p := range profile.Fields {
+			var distinct string
+			if p.Distinct != nil {
+				distinct = strconv.Itoa(*p.Distinct)
+			}
+			top := make([]string, len(p.Top))
+			for i, v := range p.Top {
+				top[i] = fmt.Sprintf("%s (%d)", v.Value, v.Count)
+			}
+			result.Rows = append(result.Rows, []string{
+				p.Field,
+				types[p.Field],
+				strconv.Itoa(p.Filled),
+				fmt.Sprintf("%.1f%%", p.FillRate*100),
+				distinct,
+				p.Min,
+				p.Max,
+				strings.Join(top, ", "),
+			})
+		}
+
+		out.Info("%s holds %d records", table.Name, profile.Records)
+		return out.Print(result)
+	},
+}
+
+// tableProfile is the structured form of a table profile.
+type tableProfile struct {
+	Table string `json:"table"`
+	*snow.DataProfile
+}
+
+func init() {
+	schemaCmd.AddCommand(schemaProfileCmd)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"sncli/internal/docs"
+)
+
+var schemaDocsCmd = &cobra.Command{
+	Use:   "docs",
+	Short: "Generate a browsable data dictionary site for selected tables",
+	Long: `Generate a static data dictionary with one HTML and one Markdown page
+per table: its own and inherited fields, choice values, incoming and
+outgoing relationships and a Mermaid diagram. index.html lists every table
+with a search box; index.md does the same for Markdown viewers.
+
+Tables are selected as for 'schema': --scope, --all-scopes, --table and
+--extends. The HTML pages load Mermaid from a CDN to draw the diagrams.`,
+	Example: `  sncli schema docs --scope x_app --out ./site
+  sncli schema docs --extends task --table 'x_*' --out ./site`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkSchemaFlags(); err != nil {
+			return err
+		}
+		if err := checkSelection(true); err != nil {
+			return err
+		}
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		tables, relationships, err := fetchSchema(cmd, client, true)
+		if err != nil {
+			return err
+		}
+
+		site := docs.Site{Title: selection.String(), Instance: client.BaseURL, Tables: tables, Relationships: relationships}
+		if err := docs.Generate(docsOut, site); err != nil {
+			return fmt.Errorf("failed to generate docs: %w", err)
+		}
+		out.Success("Generated data dictionary for %d tables in %s", len(tables), docsOut)
+		return nil
+	},
+}
+
+var docsOut string
+
+func init() {
+	schemaCmd.AddCommand(schemaDocsCmd)
+	addSelectionFlags(schemaDocsCmd)
+	schemaDocsCmd.Flags().StringVar(&docsOut, "out", "site", "Directory to write the site to")
+}
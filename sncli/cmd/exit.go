@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"errors"
+	"net/http"
+
+	"sncli/internal/snow"
+)
+
+// Process exit codes.
+const (
+	exitFailure = 1 // any other failure
+	exitUsage   = 2 // bad flags, arguments or missing input
+	exitAuth    = 3 // the instance rejected the credentials
+)
+
+// exitError is an error that has already been reported to the user and
+// carries the process exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// usageError marks err as caused by bad input.
+func usageError(err error) error {
+	return &usageErr{err}
+}
+
+type usageErr struct{ error }
+
+func (e *usageErr) Unwrap() error { return e.error }
+
+// fail prints msg and err in the error style and returns an exitError with
+// the code that fits err.
+func fail(msg string, err error) error {
+	return failWith(exitCode(err), msg, err)
+}
+
+// failWith is fail with an explicit exit code.
+func failWith(code int, msg string, err error) error {
+	out.Error(msg, err)
+	return &exitError{code: code, err: err}
+}
+
+// exitCode maps an error to the process exit code.
+func exitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	var authErr *snow.AuthError
+	if errors.As(err, &authErr) && (authErr.Status == http.StatusUnauthorized || authErr.Status == http.StatusForbidden) {
+		return exitAuth
+	}
+	var usage *usageErr
+	var queryErr *snow.QueryError
+	if errors.As(err, &usage) || errors.As(err, &queryErr) {
+		return exitUsage
+	}
+	return exitFailure
+}
+
+// reported reports whether err was already printed by fail.
+func reported(err error) bool {
+	var exitErr *exitError
+	return errors.As(err, &exitErr)
+}
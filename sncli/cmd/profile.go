@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sncli/internal/output"
+	"sncli/internal/snow"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named instance profiles",
+	Long: `Manage the named profiles stored in ~/.sncli/config.json.
+
+Every command uses the profile given with --profile, then $SNCLI_PROFILE,
+then the current profile set with 'profile use'.
+
+Table data is profiled with 'schema profile <table>'.`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return cmd.Help()
+		}
+		// 'profile <table>' is easy to reach for, but table names would
+		// clash with the subcommands
+		return usageError(fmt.Errorf("unknown profile command %q - to profile the data of a table, run 'sncli schema profile %s'", args[0], args[0]))
+	},
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := snow.LoadConfigFile()
+		if err != nil {
+			return err
+		}
+		if len(file.Profiles) == 0 && !out.Format.Structured() {
+			out.Info("No profiles yet - run 'connect' to create one")
+			return nil
+		}
+
+		active := file.Resolve(profile)
+		summaries := make([]profileSummary, 0, len(file.Profiles))
+		result := output.Result{Columns: []string{"Current", "Name", "Instance", "Auth"}}
+		for _, name := range file.Names() {
+			cfg := file.Profiles[name]
+			s := profileSummary{Name: name, Instance: cfg.Instance, Auth: authOf(cfg), Current: name == active}
+			summaries = append(summaries, s)
+
+			marker := ""
+			if s.Current {
+				marker = "*"
+			}
+			result.Rows = append(result.Rows, []string{marker, s.Name, s.Instance, string(s.Auth)})
+		}
+		result.Value = summaries
+		return out.Print(result)
+	},
+}
+
+// profileSummary is a row of 'profile list'.
+type profileSummary struct {
+	Name     string          `json:"name"`
+	Instance string          `json:"instance"`
+	Auth     snow.AuthMethod `json:"auth"`
+	Current  bool            `json:"current"`
+}
+
+// authOf returns the auth method of cfg, defaulting to basic.
+func authOf(cfg *snow.Config) snow.AuthMethod {
+	if cfg.AuthMethod == "" {
+		return snow.AuthBasic
+	}
+	return cfg.AuthMethod
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Set the current profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := snow.LoadConfigFile()
+		if err != nil {
+			return err
+		}
+		if _, ok := file.Profiles[args[0]]; !ok {
+			return fmt.Errorf("profile %q not found", args[0])
+		}
+		file.Current = args[0]
+		if err := file.Save(); err != nil {
+			return err
+		}
+		out.Success("✓ Now using profile %s", args[0])
+		return nil
+	},
+}
+
+var profileShowCmd = &cobra.Command{
+	Use:   "show [name]",
+	Short: "Show a profile (defaults to the active one)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := profile
+		if len(args) == 1 {
+			name = args[0]
+		}
+		cfg, err := snow.ReadConfig(name)
+		if err != nil {
+			return err
+		}
+
+		details := profileDetails{
+			Profile:  cfg.Profile,
+			Instance: cfg.Instance,
+			User:     cfg.Username,
+			Auth:     authOf(cfg),
+		}
+		expires := ""
+		if details.Auth == snow.AuthOAuth {
+			details.ClientID = cfg.ClientID
+			if cfg.Token != nil && !cfg.Token.Expiry.IsZero() {
+				details.TokenExpiry = &cfg.Token.Expiry
+				expires = cfg.Token.Expiry.Local().Format("2006-01-02 15:04:05")
+			}
+		}
+		return out.Print(output.Object(details,
+			[]string{"Profile", "Instance", "User", "Auth", "Client ID", "Token expires"},
+			[]string{details.Profile, details.Instance, details.User, string(details.Auth), details.ClientID, expires}))
+	},
+}
+
+// profileDetails is the result of 'profile show'. Secrets are never shown.
+type profileDetails struct {
+	Profile     string          `json:"profile"`
+	Instance    string          `json:"instance"`
+	User        string          `json:"user"`
+	Auth        snow.AuthMethod `json:"auth"`
+	ClientID    string          `json:"client_id,omitempty"`
+	TokenExpiry *time.Time      `json:"token_expiry,omitempty"`
+}
+
+var profileDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := snow.LoadConfigFile()
+		if err != nil {
+			return err
+		}
+		if _, ok := file.Profiles[args[0]]; !ok {
+			return fmt.Errorf("profile %q not found", args[0])
+		}
+		delete(file.Profiles, args[0])
+		if file.Current == args[0] {
+			file.Current = ""
+		}
+		if err := file.Save(); err != nil {
+			return err
+		}
+		out.Success("✓ Deleted profile %s", args[0])
+		return nil
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd, profileUseCmd, profileShowCmd, profileDeleteCmd)
+}
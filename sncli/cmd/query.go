@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sncli/internal/output"
+	"sncli/internal/snow"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Inspect encoded queries",
+}
+
+var queryExplainCmd = &cobra.Command{
+	Use:   "explain <table> <query>",
+	Short: "Parse, pretty-print and validate an encoded query",
+	Long: `Parse an encoded query, check its fields and operators against the
+sys_dictionary entries of the table and the tables it extends, and print it
+in readable form.
+
+ServiceNow ignores conditions it cannot understand and returns every record
+instead, so record list, update and delete run the same check first.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		parsed, err := client.ValidateQuery(cmd.Context(), args[0], args[1])
+		if err != nil {
+			return err
+		}
+
+		if !out.Format.Structured() {
+			out.Info("%s", parsed.Pretty())
+			out.Success("\n✓ Query is valid for %s", args[0])
+			return nil
+		}
+		return out.Print(queryResult(parsed))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(queryCmd)
+	queryCmd.AddCommand(queryExplainCmd)
+}
+
+// queryResult lists the conditions of a parsed query, one per row.
+func queryResult(parsed *snow.ParsedQuery) output.Result {
+	result := output.Result{
+		Value:   parsed,
+		Columns: []string{"Query", "Clause", "Join", "Field", "Operator", "Value"},
+	}
+	for i, group := range parsed.Queries {
+		for j, clause := range group.Clauses {
+			for k, cond := range clause.Any {
+				join := "AND"
+				if k > 0 {
+					join = "OR"
+				}
+				result.Rows = append(result.Rows, []string{
+					strconv.Itoa(i + 1), strconv.Itoa(j + 1), join, cond.Field, string(cond.Operator), cond.Value,
+				})
+			}
+		}
+	}
+	for _, o := range parsed.Order {
+		op := "ORDERBY"
+		if o.Desc {
+			op = "ORDERBYDESC"
+		}
+		result.Rows = append(result.Rows, []string{"", "", "", o.Field, op, ""})
+	}
+	return result
+}
+
+// checkQuery validates an encoded query against table before it selects
+// records, unless --no-validate was given.
+func checkQuery(cmd *cobra.Command, client *snow.Client, table, query string) error {
+	if query == "" || noValidate {
+		return nil
+	}
+	_, err := client.ValidateQuery(cmd.Context(), table, query)
+	var queryErr *snow.QueryError
+	if errors.As(err, &queryErr) {
+		return usageError(errors.New(strings.Join(queryErr.Problems, "\n") +
+			"\n(use --no-validate to send the query anyway)"))
+	}
+	return err
+}
@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+	"sncli/internal/output"
+	"sncli/internal/snow"
+)
+
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "List, read and change table records",
+	Long: `Work with records of any table through the Table API.
+
+Update and delete act on a single record by sys_id, or on every record
+matching --query when --yes is given.`,
+}
+
+var (
+	recordQuery        string
+	recordFields       []string
+	recordDisplayValue string
+	recordLimit        int
+	recordSet          []string
+	recordFile         string
+	recordYes          bool
+	noValidate         bool
+)
+
+var recordListCmd = &cobra.Command{
+	Use:   "list <table>",
+	Short: "List records matching an encoded query",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, opts, err := recordClient()
+		if err != nil {
+			return err
+		}
+		if err := checkQuery(cmd, client, args[0], recordQuery); err != nil {
+			return err
+		}
+		records, err := client.ListRecords(cmd.Context(), args[0], snow.ListOptions{
+			RecordOptions: opts,
+			Query:         recordQuery,
+			Limit:         recordLimit,
+		})
+		if err != nil {
+			return err
+		}
+		return out.Print(output.Records(records, recordFields))
+	},
+}
+
+var recordGetCmd = &cobra.Command{
+	Use:   "get <table> <sys_id>",
+	Short: "Get a record by sys_id",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, opts, err := recordClient()
+		if err != nil {
+			return err
+		}
+		record, err := client.GetRecord(cmd.Context(), args[0], args[1], opts)
+		if err != nil {
+			return err
+		}
+		return out.Print(output.Record(record, recordFields))
+	},
+}
+
+var recordCreateCmd = &cobra.Command{
+	Use:   "create <table>",
+	Short: "Create a record",
+	Long: `Create a record. Field values come from --set field=value flags, a JSON or
+YAML --file, or JSON/YAML on stdin; --set values override the file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, opts, err := recordClient()
+		if err != nil {
+			return err
+		}
+		fields, err := recordInput()
+		if err != nil {
+			return err
+		}
+		record, err := client.CreateRecord(cmd.Context(), args[0], fields, opts)
+		if err != nil {
+			return err
+		}
+		return out.Print(output.Record(record, recordFields))
+	},
+}
+
+var recordUpdateCmd = &cobra.Command{
+	Use:   "update <table> [sys_id]",
+	Short: "Update a record, or all records matching --query",
+	Long: `Update a record by sys_id, or every record matching --query (requires --yes).
+Field values come from --set field=value flags, a JSON or YAML --file, or
+JSON/YAML on stdin; --set values override the file.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, opts, err := recordClient()
+		if err != nil {
+			return err
+		}
+		fields, err := recordInput()
+		if err != nil {
+			return err
+		}
+		ids, err := recordTargets(cmd, client, args)
+		if err != nil {
+			return err
+		}
+
+		updated := make([]snow.Record, 0, len(ids))
+		for _, id := range ids {
+			record, err := client.UpdateRecord(cmd.Context(), args[0], id, fields, opts)
+			if err != nil {
+				return err
+			}
+			updated = append(updated, record)
+		}
+		if len(args) == 2 {
+			return out.Print(output.Record(updated[0], recordFields))
+		}
+		return out.Print(output.Records(updated, recordFields))
+	},
+}
+
+var recordDeleteCmd = &cobra.Command{
+	Use:   "delete <table> [sys_id]",
+	Short: "Delete a record, or all records matching --query",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, _, err := recordClient()
+		if err != nil {
+			return err
+		}
+		ids, err := recordTargets(cmd, client, args)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := client.DeleteRecord(cmd.Context(), args[0], id); err != nil {
+				return err
+			}
+		}
+		out.Success("✓ Deleted %d %s record(s)", len(ids), args[0])
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recordCmd)
+	recordCmd.AddCommand(recordListCmd, recordGetCmd, recordCreateCmd, recordUpdateCmd, recordDeleteCmd)
+
+	for _, c := range []*cobra.Command{recordListCmd, recordGetCmd, recordCreateCmd, recordUpdateCmd} {
+		c.Flags().StringSliceVar(&recordFields, "fields", nil, "Fields to return (comma-separated)")
+		c.Flags().StringVar(&recordDisplayValue, "display-value", "", "Return display values: true, false or all")
+	}
+	for _, c := range []*cobra.Command{recordListCmd, recordUpdateCmd, recordDeleteCmd} {
+		c.Flags().StringVarP(&recordQuery, "query", "q", "", "Encoded query selecting the records")
+		c.Flags().BoolVar(&noValidate, "no-validate", false, "Send --query without checking it against the table's dictionary")
+	}
+	recordListCmd.Flags().IntVar(&recordLimit, "limit", 0, "Maximum number of records to return (0 for all)")
+	for _, c := range []*cobra.Command{recordCreateCmd, recordUpdateCmd} {
+		c.Flags().StringArrayVar(&recordSet, "set", nil, "Field value as field=value (repeatable)")
+		c.Flags().StringVarP(&recordFile, "file", "f", "", "JSON or YAML file with field values ('-' for stdin)")
+	}
+	for _, c := range []*cobra.Command{recordUpdateCmd, recordDeleteCmd} {
+		c.Flags().BoolVar(&recordYes, "yes", false, "Confirm changing every record matching --query")
+	}
+}
+
+// recordClient creates the client and the record options shared by the
+// record subcommands.
+func recordClient() (*snow.Client, snow.RecordOptions, error) {
+	if !snow.ValidDisplayValue(recordDisplayValue) {
+		return nil, snow.RecordOptions{}, usageError(fmt.Errorf("--display-value must be true, false or all"))
+	}
+	client, err := newClient()
+	if err != nil {
+		return nil, snow.RecordOptions{}, err
+	}
+	return client, snow.RecordOptions{Fields: recordFields, DisplayValue: recordDisplayValue}, nil
+}
+
+// recordTargets resolves the sys_ids an update or delete applies to: the
+// sys_id argument, or every record matching --query.
+func recordTargets(cmd *cobra.Command, client *snow.Client, args []string) ([]string, error) {
+	switch {
+	case len(args) == 2 && recordQuery != "":
+		return nil, usageError(errors.New("give either a sys_id or --query, not both"))
+	case len(args) == 2:
+		return []string{args[1]}, nil
+	case recordQuery == "":
+		return nil, usageError(errors.New("a sys_id or --query is required"))
+	case !recordYes:
+		return nil, usageError(errors.New("--yes is required to change every record matching --query"))
+	}
+	if err := checkQuery(cmd, client, args[0], recordQuery); err != nil {
+		return nil, err
+	}
+
+	records, err := client.ListRecords(cmd.Context(), args[0], snow.ListOptions{
+		RecordOptions: snow.RecordOptions{Fields: []string{"sys_id"}},
+		Query:         recordQuery,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(records))
+	for _, r := range records {
+		if id, ok := r["sys_id"].(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// recordInput gathers field values from --file (or stdin) and --set.
+func recordInput() (snow.Record, error) {
+	fields := snow.Record{}
+
+	var data []byte
+	var err error
+	switch {
+	case recordFile == "-":
+		data, err = io.ReadAll(os.Stdin)
+	case recordFile != "":
+		data, err = os.ReadFile(recordFile)
+	case len(recordSet) == 0 && !term.IsTerminal(int(os.Stdin.Fd())):
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read field values: %w", err)
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := decodeFields(data, filepath.Ext(recordFile), fields); err != nil {
+			return nil, usageError(err)
+		}
+	}
+
+	for _, kv := range recordSet {
+		field, value, ok := strings.Cut(kv, "=")
+		if !ok || field == "" {
+			return nil, usageError(fmt.Errorf("--set %q: want field=value", kv))
+		}
+		fields[field] = value
+	}
+
+	if len(fields) == 0 {
+		return nil, usageError(errors.New("no field values given (use --set, --file or stdin)"))
+	}
+	return fields, nil
+}
+
+// decodeFields parses a JSON or YAML object into fields. JSON is tried first
+// unless the file extension says YAML; YAML is a superset of JSON anyway.
+func decodeFields(data []byte, ext string, fields snow.Record) error {
+	if ext != ".yaml" && ext != ".yml" {
+		if err := json.Unmarshal(data, &fields); err == nil {
+			return nil
+		}
+	}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("field values must be a JSON or YAML object: %v", err)
+	}
+	return nil
+}
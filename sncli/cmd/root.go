@@ -1,20 +1,128 @@
-	package cmd
+package cmd
 
-	import (
-	  "github.com/spf13/cobra"
-	)
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
 
-	var rootCmd = &cobra.Command{
-	  Use:   "sncli",
-	  Short: "ServiceNow CLI Tool",
-	  Long:  "A CLI tool to interact with ServiceNow instances",
+	"github.com/spf13/cobra"
+	"sncli/internal/output"
+	"sncli/internal/snow"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "sncli",
+	Short: "ServiceNow CLI Tool",
+	Long: `A CLI tool to interact with ServiceNow instances
+
+Results are printed in the format chosen with --output. The structured
+formats (json, yaml, csv, ndjson) send status messages to stderr so stdout
+holds only data. Warnings and errors always go to stderr. Colors are used on terminals unless --no-color or
+NO_COLOR is set.
+
+Exit codes: 0 success, 1 failure, 2 invalid usage or missing input,
+3 authentication rejected by the instance.`,
+	SilenceErrors:     true,
+	SilenceUsage:      true,
+	PersistentPreRunE: setupOutput,
+}
+
+var (
+	profile string
+	timeout time.Duration
+	retries int
+
+	outputFormat string
+	noColor      bool
+
+	noCache bool
+	refresh bool
+
+	// out renders results and messages for every command.
+	out = output.New(output.Table, false)
+)
+
+// Execute runs the root command. Interrupting the process cancels the
+// command's context, which aborts any in-flight ServiceNow request. Errors
+// not already reported by the command are printed to stderr.
+func Execute() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := rootCmd.ExecuteContext(ctx)
+	if err != nil && !reported(err) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 	}
+	return err
+}
 
-	func Execute() error {
-	  return rootCmd.Execute()
+// ExitCode returns the process exit code for an error from Execute.
+func ExitCode(err error) int {
+	return exitCode(err)
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Config profile to use (default $"+snow.ProfileEnv+" or the current profile)")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", snow.DefaultTimeout, "Timeout for each HTTP request to ServiceNow")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", snow.DefaultRetryPolicy.MaxRetries, "Retries for throttled or failed requests")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", string(output.Table), "Output format: table, json, yaml, csv or ndjson")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (also $"+output.NoColorEnv+")")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Do not read or write the metadata cache")
+	rootCmd.PersistentFlags().BoolVar(&refresh, "refresh", false, "Fetch metadata again and replace the cached copy")
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return usageError(fmt.Errorf("%w\nRun '%s --help' for usage", err, cmd.CommandPath()))
+	})
+	rootCmd.AddCommand(connectCmd)
+	rootCmd.AddCommand(profileCmd)
+}
+
+// setupOutput creates the printer for the global --output and --no-color
+// flags.
+func setupOutput(cmd *cobra.Command, args []string) error {
+	legacyOut := cmd == schemaCmd && legacySchemaOut(cmd)
+	format, err := output.ParseFormat(outputFormat)
+	if err != nil {
+		return usageError(err)
+	}
+	out = output.New(format, noColor)
+	if legacyOut {
+		out.Warn("schema -o no longer names the output file and will stop accepting one; use --out %s", schemaOut)
+	}
+	return nil
+}
+
+// configureClient applies the global request flags to a client.
+func configureClient(c *snow.Client) {
+	c.Timeout = timeout
+	c.Retry.MaxRetries = retries
+}
+
+// newClient creates a client for the selected profile with the global
+// request flags applied.
+func newClient() (*snow.Client, error) {
+	return newProfileClient(profile)
+}
+
+// newProfileClient is newClient for the named profile, or the default one
+// when name is empty.
+func newProfileClient(name string) (*snow.Client, error) {
+	cfg, err := snow.ReadConfig(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
 	}
 
-	func init() {
-	  rootCmd.AddCommand(connectCmd)
+	client, err := snow.NewClientFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ServiceNow client: %v", err)
 	}
+	configureClient(client)
 
+	if !noCache {
+		if client.Cache, err = snow.NewCache(client.BaseURL, cfg.CacheUser()); err != nil {
+			return nil, err
+		}
+		client.Cache.Refresh = refresh
+	}
+	return client, nil
+}
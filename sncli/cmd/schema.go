@@ -1,126 +1,440 @@
-	package cmd
-
-	import (
-	  "encoding/csv"
-	  "fmt"
-	  "os"
-	  "strings"
-
-	  "github.com/spf13/cobra"
-	  "sncli/internal/snow"
-	)
-
-	var schemaCmd = &cobra.Command{
-	  Use:   "schema",
-	  Short: "Export table schema for ERD",
-	  Long: `Export ServiceNow table schemas and relationships for ERD generation.
-	Supports scoped application filtering and outputs in CSV format suitable for tools like Lucidchart.`,
-	  RunE: runSchema,
-	}
-
-	var (
-	  scope    string
-	  output   string
-	  detailed bool
-	)
-
-	func init() {
-	  rootCmd.AddCommand(schemaCmd)
-	  schemaCmd.Flags().StringVarP(&scope, "scope", "s", "", "Application scope to filter tables (required)")
-	  schemaCmd.Flags().StringVarP(&output, "output", "o", "tables.csv", "Output CSV file path")
-	  schemaCmd.Flags().BoolVarP(&detailed, "detailed", "d", false, "Include detailed field information")
-	  schemaCmd.MarkFlagRequired("scope")
-	}
-
-	func runSchema(cmd *cobra.Command, args []string) error {
-			cfg, err := snow.ReadConfig()
-			if err != nil {
-			    return fmt.Errorf("failed to read config: %w", err)
-			}
+package cmd
 
-			client, err := snow.NewClient(cfg.Instance, cfg.Username, cfg.Password)
-	  if err != nil {
-	    return fmt.Errorf("failed to create ServiceNow client: %v", err)
-	  }
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
 
-			fmt.Printf("Fetching tables for scope: %s...\n", scope)
-			tables, err := client.GetTables(scope, detailed)
-			if err != nil {
-			  return fmt.Errorf("failed to fetch tables: %w", err)
-			}
+	"github.com/spf13/cobra"
+	"sncli/internal/erd"
+	"sncli/internal/output"
+	"sncli/internal/snow"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Export table schema for ERD",
+	Long: `Export ServiceNow table schemas and relationships for ERD generation.
+
+Tables are selected by application scope with --scope, repeatable, or from
+every scope with --all-scopes. --table narrows them down to names matching
+a glob such as 'x_app_*' or a regular expression between slashes, and
+--extends task to the tables extending task, directly or not. Tables
+outside the selection that selected tables relate to are drawn as external
+stubs in diagrams, so cross-scope references stay visible.
+
+The export is written to --out, by default as CSV with one row per table.
+--format mermaid, plantuml, dot or dbml writes an entity relationship
+diagram instead, --format lucid a Lucidchart database import with one row
+per column and --format drawio a laid-out draw.io diagram. --output json,
+yaml, ndjson or table writes the export in that format.
+Use --out - to write to stdout. -o used to name the output file; a file
+name given to it still works for now, with a warning.
+
+Relationships cover reference, glide_list and document_id fields in both
+directions, table inheritance, many-to-many tables (sys_m2m) and related
+lists (sys_relationship), each with its cardinality.
+
+--detailed adds the fields of each table, inherited ones included, with
+their dictionary details: default value, display flag, dependent field,
+attributes, reference qualifier and choice values.
+
+--profile-data adds the record count of each table from the Aggregate API.
+With --detailed it also adds the fill rate of every field, the number of
+distinct values of choice and reference fields and the range of date
+fields. Use 'schema profile <table>' to profile a single table in depth.`,
+	Args: cobra.NoArgs,
+	RunE: runSchema,
+}
+
+var (
+	schemaOut    string
+	schemaFormat string
+	detailed     bool
+	profileData  bool
+	pageSize     int
+	maxRecords   int
+	concurrency  int
+	batchSize    int
+)
+
+// Table selection flags, shared by the schema commands
+var (
+	scopes        []string
+	allScopes     bool
+	tablePatterns []string
+	extends       string
+	selection     snow.TableSelection
+)
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	addSelectionFlags(schemaCmd)
+	schemaCmd.Flags().StringVar(&schemaOut, "out", "", "Output file path, or - for stdout (default tables.<format>)")
+	schemaCmd.Flags().StringVar(&schemaFormat, "format", "", "Export format: csv, mermaid, plantuml, dot, dbml, lucid or drawio (default csv)")
+	schemaCmd.Flags().BoolVarP(&detailed, "detailed", "d", false, "Include detailed field information")
+	schemaCmd.Flags().BoolVar(&profileData, "profile-data", false, "Add record counts, and with --detailed field statistics, from the Aggregate API")
+	schemaCmd.PersistentFlags().IntVar(&pageSize, "page-size", snow.DefaultPageSize, "Records requested per page from the Table API")
+	schemaCmd.PersistentFlags().IntVar(&maxRecords, "max-records", snow.DefaultMaxRecords, "Maximum records a single table query may return")
+	schemaCmd.PersistentFlags().IntVar(&concurrency, "concurrency", snow.DefaultConcurrency, "Tables whose metadata is fetched at once")
+	schemaCmd.PersistentFlags().IntVar(&batchSize, "batch-size", snow.DefaultBatchSize, "Metadata requests bundled into one Batch API call (1 disables batching)")
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	if err := checkSchemaFlags(); err != nil {
+		return err
+	}
+	if err := checkSelection(true); err != nil {
+		return err
+	}
+	if schemaOut == "-" {
+		// Keep messages out of the export
+		out = out.To(os.Stderr)
+	}
+
+	// CSV stays the default for schema exports
+	format := out.Format
+	var diagram erd.Format
+	switch {
+	case schemaFormat != "" && cmd.Flags().Changed("output"):
+		return usageError(fmt.Errorf("--format and --output cannot be combined"))
+	case strings.EqualFold(schemaFormat, string(output.CSV)),
+		schemaFormat == "" && !cmd.Flags().Changed("output"):
+		format = output.CSV
+	case schemaFormat != "":
+		var err error
+		if diagram, err = erd.ParseFormat(schemaFormat); err != nil {
+			return usageError(fmt.Errorf("unknown schema format %q (want csv, mermaid, plantuml, dot, dbml, lucid or drawio)", schemaFormat))
+		}
+	}
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+	tables, relationships, err := fetchSchema(cmd, client, detailed)
+	if err != nil {
+		return err
+	}
+	if profileData {
+		out.Info("Profiling the data of %d tables...", len(tables))
+		if err := client.ProfileTables(cmd.Context(), tables, false); err != nil {
+			return fmt.Errorf("failed to profile tables: %w", err)
+		}
+	}
+
+	path := schemaOut
+	if path == "" {
+		path = "tables." + schemaExt(format)
+		if diagram != "" {
+			path = "tables." + diagram.Ext()
+		}
+	}
+	printer := *out
+	printer.Format = format
+	write := func(w io.Writer) error {
+		if diagram != "" {
+			return erd.Write(w, diagram, erd.Schema{Name: selection.String(), Tables: tables, Relationships: relationships})
+		}
+		return printer.To(w).Print(schemaResult(tables, relationships))
+	}
+	if path == "-" {
+		return write(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	defer f.Close()
+	if err := write(f); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	out.Success("Successfully exported schema to %s", path)
+	return nil
+}
+
+// legacySchemaOut accepts the file name schema took with -o/--output
+// before that flag chose the output format. A value that is no format but
+// looks like a file moves to --out, and the output format goes back to its
+// default. It reports whether it did so.
+func legacySchemaOut(cmd *cobra.Command) bool {
+	flag := cmd.Flags().Lookup("output")
+	if _, err := output.ParseFormat(outputFormat); err == nil || !flag.Changed || cmd.Flags().Changed("out") {
+		return false
+	}
+	if !strings.ContainsAny(outputFormat, "./"+string(os.PathSeparator)) && outputFormat != "-" {
+		return false
+	}
+	schemaOut = outputFormat
+	outputFormat = flag.DefValue
+	flag.Changed = false
+	return true
+}
+
+// checkSchemaFlags validates the request flags shared by the schema
+// commands.
+func checkSchemaFlags() error {
+	if concurrency < 1 {
+		return usageError(fmt.Errorf("--concurrency must be at least 1"))
+	}
+	if batchSize < 1 {
+		return usageError(fmt.Errorf("--batch-size must be at least 1"))
+	}
+	return nil
+}
+
+// addSelectionFlags adds the table selection flags to cmd.
+func addSelectionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&scopes, "scope", "s", nil, "Application scope to select tables from (repeatable)")
+	cmd.Flags().BoolVar(&allScopes, "all-scopes", false, "Select tables from every scope")
+	// A slice flag would split regular expressions on commas
+	cmd.Flags().StringArrayVar(&tablePatterns, "table", nil, "Table name glob, or regular expression between slashes (repeatable)")
+	cmd.Flags().StringVar(&extends, "extends", "", "Select the tables extending this base table")
+}
+
+// checkSelection builds the table selection from its flags. A required
+// selection must have at least one criterion, so that a command never
+// fetches every table of an instance by accident.
+func checkSelection(required bool) error {
+	if allScopes && len(scopes) > 0 {
+		return usageError(fmt.Errorf("--scope and --all-scopes cannot be combined"))
+	}
+	selection = snow.TableSelection{Scopes: scopes, AllScopes: allScopes, Tables: tablePatterns, Extends: extends}
+	if err := selection.Validate(); err != nil {
+		return usageError(err)
+	}
+	if required && selection.Empty() {
+		return usageError(fmt.Errorf("select tables with --scope, --all-scopes, --table or --extends"))
+	}
+	return nil
+}
+
+// fetchSchema fetches the selected tables and their relationships with
+// the schema request flags applied to client.
+func fetchSchema(cmd *cobra.Command, client *snow.Client, detailed bool) ([]snow.Table, []snow.RelationshipInfo, error) {
+	tables, err := fetchTables(cmd, client, detailed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out.Info("Found %d tables, fetching relationships...", len(tables))
+	relationships, err := client.GetRelationships(cmd.Context(), tables)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch relationships: %w", err)
+	}
+
+	return tables, relationships, nil
+}
+
+// applySchemaFlags sets the request flags of schema commands on client and
+// reports its progress.
+func applySchemaFlags(client *snow.Client) {
+	client.PageSize = pageSize
+	client.MaxRecords = maxRecords
+	client.Concurrency = concurrency
+	client.BatchSize = batchSize
+	client.Progress = func(task string, done, total int) {
+		out.Progress("Fetching "+task, done, total)
+	}
+}
+
+// fetchTables fetches the selected tables with the schema request flags
+// applied to client.
+func fetchTables(cmd *cobra.Command, client *snow.Client, detailed bool) ([]snow.Table, error) {
+	applySchemaFlags(client)
+	out.Info("Fetching tables for %s...", selection)
+	tables, err := client.SelectTables(cmd.Context(), selection, detailed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tables: %w", err)
+	}
+	return tables, nil
+}
 
-			fmt.Printf("Found %d tables, fetching relationships...\n", len(tables))
-			relationships, err := client.GetRelationships(tables)
-			if err != nil {
-			  return fmt.Errorf("failed to fetch relationships: %w", err)
+// schemaResult lays out an export with one row per table.
+func schemaResult(tables []snow.Table, relationships []snow.RelationshipInfo) output.Result {
+	// Build header
+	header := []string{"Table Name", "Label", "Description", "Super Class", "Hierarchy", "Properties", "Outgoing Relationships", "Incoming Relationships"}
+	if profileData {
+		header = append(header, "Records")
+	}
+	if detailed {
+		header = append(header, "Fields")
+	}
+	result := output.Result{
+		Value:   schemaExport{Selection: selection, Tables: tables, Relationships: relationships},
+		Columns: header,
+	}
+
+	// Build table rows
+	for _, table := range tables {
+		outgoing := make([]string, 0)
+		incoming := make([]string, 0)
+		for _, rel := range relationships {
+			if rel.SourceTable == table.Name {
+				outgoing = append(outgoing, describeOutgoing(rel))
+			}
+			if rel.TargetTable == table.Name {
+				incoming = append(incoming, describeIncoming(rel))
 			}
+		}
 
-	  f, err := os.Create(output)
-	  if err != nil {
-	    return fmt.Errorf("failed to create output file: %v", err)
-	  }
-	  defer f.Close()
+		properties := []string{
+			fmt.Sprintf("Access: %s", table.AccessibleFrom),
+			fmt.Sprintf("Extendable: %v", table.Extendable),
+			fmt.Sprintf("Number Prefix: %s", table.NumberPrefix),
+		}
 
-	  w := csv.NewWriter(f)
-	  defer w.Flush()
+		record := []string{
+			table.Name,
+			table.Label,
+			table.Description,
+			table.SuperClass,
+			table.HierarchyPath(),
+			strings.Join(properties, "\n"),
+			strings.Join(outgoing, "\n"),
+			strings.Join(incoming, "\n"),
+		}
 
-	  // Write header
-			header := []string{"Table Name", "Label", "Description", "Super Class", "Properties", "Parent Relationships", "Referenced Relationships"}
-			if detailed {
-			  header = append(header, "Fields")
+		if profileData {
+			var records string
+			if table.Profile != nil {
+				records = strconv.Itoa(table.Profile.Records)
 			}
-	  if err := w.Write(header); err != nil {
-	    return fmt.Errorf("failed to write CSV header: %v", err)
-	  }
-
-	  // Write table data
-			for _, table := range tables {
-			  parentRels := make([]string, 0)
-			  referenceRels := make([]string, 0)
-			  for _, rel := range relationships {
-			    if rel.ParentTable == table.Name {
-			      parentRels = append(parentRels, fmt.Sprintf("%s (%s)", rel.ChildTable, rel.Type))
-			    }
-			    if rel.ReferencedTable == table.Name {
-			      referenceRels = append(referenceRels, fmt.Sprintf("%s.%s", rel.SourceTable, rel.Field))
-			    }
-			  }
-
-			  properties := []string{
-			    fmt.Sprintf("Access: %s", table.AccessibleFrom),
-			    fmt.Sprintf("Extendable: %v", table.Extendable),
-			    fmt.Sprintf("Number Prefix: %s", table.NumberPrefix),
-			  }
-
-			  record := []string{
-			    table.Name,
-			    table.Label,
-			    table.Description,
-			    table.SuperClass,
-			    strings.Join(properties, "\n"),
-			    strings.Join(parentRels, "\n"),
-			    strings.Join(referenceRels, "\n"),
-			  }
-
-			  if detailed {
-			    fields := make([]string, 0)
-			    for _, f := range table.Fields {
-			      fieldProps := []string{
-			        fmt.Sprintf("Type: %s", f.Type),
-			        fmt.Sprintf("Length: %d", f.Length),
-			        fmt.Sprintf("Reference: %s", f.Reference),
-			      }
-			      fields = append(fields, fmt.Sprintf("%s\n  %s", f.Name, strings.Join(fieldProps, ", ")))
-			    }
-			    record = append(record, strings.Join(fields, "\n"))
-			  }
-
-	    if err := w.Write(record); err != nil {
-	      return fmt.Errorf("failed to write table record: %v", err)
-	    }
-	  }
-
-	  fmt.Printf("Successfully exported schema to %s\n", output)
-	  return nil
+			record = append(record, records)
+		}
+		if detailed {
+			profiles := map[string]snow.FieldProfile{}
+			if table.Profile != nil {
+				for _, p := range table.Profile.Fields {
+					profiles[p.Field] = p
+				}
+			}
+			fields := make([]string, 0)
+			for _, f := range table.Fields {
+				fieldProps := []string{
+					fmt.Sprintf("Type: %s", f.Type),
+					fmt.Sprintf("Length: %d", f.Length),
+					fmt.Sprintf("Reference: %s", f.Reference),
+				}
+				fieldProps = append(fieldProps, fieldDetails(f)...)
+				if p, ok := profiles[f.Name]; ok {
+					fieldProps = append(fieldProps, fieldStatistics(p)...)
+				}
+				if f.Inherited {
+					fieldProps = append(fieldProps, fmt.Sprintf("Inherited from: %s", f.DefinedOn))
+				}
+				fields = append(fields, fmt.Sprintf("%s\n  %s", f.Name, strings.Join(fieldProps, ", ")))
+			}
+			record = append(record, strings.Join(fields, "\n"))
+		}
+
+		result.Rows = append(result.Rows, record)
+	}
+
+	return result
+}
+
+// fieldDetails lists the dictionary details of a field that are set, such
+// as "Default: 1" or "Choices: 1=High, 2=Low".
+func fieldDetails(f snow.TableField) []string {
+	var details []string
+	if f.Label != "" {
+		details = append(details, fmt.Sprintf("Label: %s", f.Label))
+	}
+	if f.IsMandatory {
+		details = append(details, "Mandatory")
+	}
+	if f.IsUnique {
+		details = append(details, "Unique")
+	}
+	if f.Display {
+		details = append(details, "Display")
+	}
+	if f.DefaultValue != "" {
+		details = append(details, fmt.Sprintf("Default: %s", f.DefaultValue))
+	}
+	if f.DependentOn != "" {
+		details = append(details, fmt.Sprintf("Depends on: %s", f.DependentOn))
+	}
+	if f.ReferenceQualifier != "" {
+		details = append(details, fmt.Sprintf("Reference qualifier: %s", f.ReferenceQualifier))
+	}
+	if len(f.Attributes) > 0 {
+		details = append(details, fmt.Sprintf("Attributes: %s", f.AttributeString()))
+	}
+	if len(f.Choices) > 0 {
+		choices := make([]string, len(f.Choices))
+		for i, c := range f.Choices {
+			choices[i] = c.Value + "=" + c.Label
+		}
+		details = append(details, fmt.Sprintf("Choices: %s", strings.Join(choices, ", ")))
 	}
+	return details
+}
 
+// fieldStatistics lists the data profile of a field, such as
+// "Filled: 80% (40)" or "Range: 2024-01-01 to 2024-06-30".
+func fieldStatistics(p snow.FieldProfile) []string {
+	stats := []string{fmt.Sprintf("Filled: %.0f%% (%d)", p.FillRate*100, p.Filled)}
+	if p.Distinct != nil {
+		stats = append(stats, fmt.Sprintf("Distinct: %d", *p.Distinct))
+	}
+	if p.Min != "" || p.Max != "" {
+		stats = append(stats, fmt.Sprintf("Range: %s to %s", p.Min, p.Max))
+	}
+	return stats
+}
+
+// schemaExport is the structured form of a schema export.
+type schemaExport struct {
+	Selection     snow.TableSelection     `json:"selection"`
+	Tables        []snow.Table            `json:"tables"`
+	Relationships []snow.RelationshipInfo `json:"relationships"`
+}
+
+// describeOutgoing renders a relationship in the row of its source table,
+// for example "caller_id -> sys_user (reference, N:1)".
+func describeOutgoing(rel snow.RelationshipInfo) string {
+	var s string
+	switch rel.Type {
+	case snow.Inheritance:
+		s = "extends " + rel.TargetTable
+	case snow.ManyToMany:
+		s = fmt.Sprintf("%s via %s", rel.TargetTable, rel.Via)
+	case snow.RelatedList:
+		s = fmt.Sprintf("%s -> %s", rel.Label, rel.TargetTable)
+	case snow.DocumentID:
+		s = fmt.Sprintf("%s -> %s (table in %s)", rel.Field, rel.TargetTable, rel.Via)
+	default:
+		s = fmt.Sprintf("%s -> %s", rel.Field, rel.TargetTable)
+	}
+	return fmt.Sprintf("%s (%s, %s)", s, rel.Type, rel.Cardinality)
+}
+
+// describeIncoming renders a relationship in the row of its target table,
+// for example "incident.caller_id (reference, N:1)".
+func describeIncoming(rel snow.RelationshipInfo) string {
+	var s string
+	switch rel.Type {
+	case snow.Inheritance:
+		s = "extended by " + rel.SourceTable
+	case snow.ManyToMany:
+		s = fmt.Sprintf("%s via %s", rel.SourceTable, rel.Via)
+	case snow.RelatedList:
+		s = fmt.Sprintf("%s on %s", rel.Label, rel.SourceTable)
+	default:
+		s = fmt.Sprintf("%s.%s", rel.SourceTable, rel.Field)
+	}
+	return fmt.Sprintf("%s (%s, %s)", s, rel.Type, rel.Cardinality)
+}
+
+// schemaExt is the default file extension for a schema export format.
+func schemaExt(format output.Format) string {
+	if format == output.Table {
+		return "txt"
+	}
+	return string(format)
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sncli/internal/output"
+	"sncli/internal/snapshot"
+)
+
+var schemaSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save the schema of selected tables as a versioned JSON snapshot",
+	Long: `Fetch every selected table with its fields, inheritance and
+relationships and save it as a JSON snapshot, for comparing later with
+'schema diff'. Tables are selected as for 'schema': --scope, --all-scopes,
+--table and --extends. The snapshot records the selection.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkSchemaFlags(); err != nil {
+			return err
+		}
+		if err := checkSelection(true); err != nil {
+			return err
+		}
+		if snapshotOut == "-" {
+			// Keep messages out of the snapshot
+			out = out.To(os.Stderr)
+		}
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		tables, relationships, err := fetchSchema(cmd, client, true)
+		if err != nil {
+			return err
+		}
+		snap := snapshot.New(client.BaseURL, selection, tables, relationships)
+
+		path := snapshotOut
+		if path == "" {
+			path = "schema.snapshot.json"
+			if len(scopes) == 1 && selection.Tables == nil && selection.Extends == "" {
+				path = scopes[0] + ".snapshot.json"
+			}
+		}
+		if path == "-" {
+			return snap.Write(os.Stdout)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create snapshot file: %v", err)
+		}
+		defer f.Close()
+		if err := snap.Write(f); err != nil {
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		out.Success("Saved snapshot of %d tables to %s", len(tables), path)
+		return nil
+	},
+}
+
+var schemaDiffCmd = &cobra.Command{
+	Use:   "diff <from> <to>",
+	Short: "Compare two schema snapshots, profiles or a snapshot with live",
+	Long: `Compare two schemas and report added and removed tables and fields,
+changes to field type, length and reference, and changed inheritance.
+Field details are compared too: mandatory, unique, display, default value,
+dependent field, reference qualifier, attributes and choices. Snapshots
+from older versions of sncli have no details to compare.
+
+Each side is a snapshot file written by 'schema snapshot', "live" for the
+selected profile, or profile:<name> for another profile. Live sides fetch
+the tables selected with --scope, --all-scopes, --table and --extends, by
+default those of a snapshot being compared.
+
+The report is plain text, Markdown with --markdown, or structured with
+--output json, yaml, csv or ndjson.`,
+	Example: `  sncli schema diff x_app.snapshot.json live
+  sncli schema diff profile:dev profile:prod --scope x_app --markdown`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if diffMarkdown && out.Format.Structured() {
+			return usageError(fmt.Errorf("--markdown and --output cannot be combined"))
+		}
+		if err := checkSchemaFlags(); err != nil {
+			return err
+		}
+		if err := checkSelection(false); err != nil {
+			return err
+		}
+
+		// Files first: their selection is the default for live sides
+		sides := make([]*snapshot.Snapshot, len(args))
+		for i, arg := range args {
+			if liveProfile(arg) {
+				continue
+			}
+			snap, err := snapshot.Read(arg)
+			if err != nil {
+				return usageError(err)
+			}
+			sides[i] = snap
+			if selection.Empty() {
+				selection = snap.TableSelection()
+			}
+		}
+		for i, arg := range args {
+			if !liveProfile(arg) {
+				continue
+			}
+			if selection.Empty() {
+				return usageError(fmt.Errorf("select tables with --scope, --all-scopes, --table or --extends to compare live schemas"))
+			}
+			name := profile
+			if arg != "live" {
+				name = strings.TrimPrefix(arg, "profile:")
+			}
+			client, err := newProfileClient(name)
+			if err != nil {
+				return err
+			}
+			tables, relationships, err := fetchSchema(cmd, client, true)
+			if err != nil {
+				return err
+			}
+			sides[i] = snapshot.New(client.BaseURL, selection, tables, relationships)
+		}
+
+		diff := snapshot.Compare(sides[0], sides[1])
+		switch {
+		case out.Format.Structured():
+			return out.Print(output.Result{
+				Value:   diff,
+				Columns: []string{"Table", "Change", "Field", "Property", "From", "To"},
+				Rows:    diff.Rows(),
+			})
+		case diffMarkdown:
+			return diff.WriteMarkdown(out.Out)
+		}
+		return diff.WriteText(out.Out)
+	},
+}
+
+var (
+	snapshotOut  string
+	diffMarkdown bool
+)
+
+func init() {
+	schemaCmd.AddCommand(schemaSnapshotCmd, schemaDiffCmd)
+	addSelectionFlags(schemaSnapshotCmd)
+	schemaSnapshotCmd.Flags().StringVar(&snapshotOut, "out", "", "Snapshot file path, or - for stdout (default <scope>.snapshot.json for a single scope, else schema.snapshot.json)")
+	addSelectionFlags(schemaDiffCmd)
+	schemaDiffCmd.Flags().BoolVar(&diffMarkdown, "markdown", false, "Write the report as Markdown")
+}
+
+// liveProfile reports whether a diff side is fetched from an instance
+// rather than read from a snapshot file.
+func liveProfile(arg string) bool {
+	return arg == "live" || strings.HasPrefix(arg, "profile:")
+}
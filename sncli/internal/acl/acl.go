@@ -0,0 +1,184 @@
+// Package acl builds the access control inventory of a scope: which roles
+// each table, field and operation requires, with the gaps worth auditing.
+package acl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sncli/internal/output"
+	"sncli/internal/snow"
+)
+
+// Kinds of findings.
+const (
+	// NoReadACL is a table without a read ACL on it or any table it
+	// extends.
+	NoReadACL = "no_read_acl"
+	// PublicAccess is a rule granting access to the public role.
+	PublicAccess = "public_access"
+)
+
+// Report is the access control inventory of a scope.
+type Report struct {
+	// Scope describes the tables audited: a scope name, or the table
+	// selection they were picked by.
+	Scope    string        `json:"scope"`
+	Tables   []TableAccess `json:"tables"`
+	Findings []Finding     `json:"findings"`
+}
+
+// TableAccess lists the ACLs defined on one table and its fields.
+type TableAccess struct {
+	Table string `json:"table"`
+	// ReadFrom is the nearest table of the hierarchy with a read ACL on
+	// whole records, the table itself or one it extends and inherits the
+	// rules of. It is empty when there is none.
+	ReadFrom string     `json:"read_from,omitempty"`
+	Rules    []snow.ACL `json:"rules"`
+}
+
+// Finding is an access gap worth auditing.
+type Finding struct {
+	Kind      string `json:"kind"`
+	Table     string `json:"table"`
+	Field     string `json:"field,omitempty"`
+	Operation string `json:"operation,omitempty"`
+	Message   string `json:"message"`
+}
+
+// New builds the report of tables from acls, which hold the rules of the
+// tables and of every table they extend.
+func New(scope string, tables []snow.Table, acls []snow.ACL) *Report {
+	r := &Report{Scope: scope, Tables: []TableAccess{}, Findings: []Finding{}}
+	byTable := map[string][]snow.ACL{}
+	for _, a := range acls {
+		byTable[a.Table] = append(byTable[a.Table], a)
+	}
+
+	sorted := append([]snow.Table(nil), tables...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, t := range sorted {
+		ta := TableAccess{Table: t.Name, Rules: byTable[t.Name]}
+		if ta.Rules == nil {
+			ta.Rules = []snow.ACL{}
+		}
+		chain := t.Hierarchy
+		if len(chain) == 0 {
+			chain = []string{t.Name}
+		}
+		for _, name := range chain {
+			if hasRecordRead(byTable[name]) {
+				ta.ReadFrom = name
+				break
+			}
+		}
+		if ta.ReadFrom == "" {
+			r.Findings = append(r.Findings, Finding{
+				Kind:      NoReadACL,
+				Table:     t.Name,
+				Operation: "read",
+				Message:   fmt.Sprintf("%s has no read ACL on it or the tables it extends", t.Name),
+			})
+		}
+		for _, a := range ta.Rules {
+			if a.Public() {
+				r.Findings = append(r.Findings, Finding{
+					Kind:      PublicAccess,
+					Table:     t.Name,
+					Field:     a.Field,
+					Operation: a.Operation,
+					Message:   fmt.Sprintf("%s grants %s to the public role", a.Name, a.Operation),
+				})
+			}
+		}
+		r.Tables = append(r.Tables, ta)
+	}
+	return r
+}
+
+// hasRecordRead reports whether acls include a read rule on whole records.
+func hasRecordRead(acls []snow.ACL) bool {
+	for _, a := range acls {
+		if a.Field == "" && a.Operation == "read" {
+			return true
+		}
+	}
+	return false
+}
+
+// Rows flattens r into the table, field, operation and roles matrix, one
+// row per rule, with the columns Table, Field, Operation, Roles, Condition
+// and Flags. A table without a read ACL gets a row of its own flagging it.
+func (r *Report) Rows() [][]string {
+	var rows [][]string
+	for _, t := range r.Tables {
+		if t.ReadFrom == "" {
+			rows = append(rows, []string{t.Table, "", "read", "", "", "no read ACL"})
+		}
+		for _, a := range t.Rules {
+			var flags string
+			if a.Public() {
+				flags = "public"
+			}
+			rows = append(rows, []string{t.Table, a.Field, a.Operation, strings.Join(a.Roles, ", "), condition(a), flags})
+		}
+	}
+	return rows
+}
+
+// condition describes what a rule checks besides roles.
+func condition(a snow.ACL) string {
+	var parts []string
+	if a.Condition != "" {
+		parts = append(parts, a.Condition)
+	}
+	if a.Scripted {
+		parts = append(parts, "script")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// WriteMarkdown writes r as a Markdown report: the findings, then a table
+// of rules per table.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# ACLs of `%s`\n\n", r.Scope)
+	b.WriteString("## Findings\n\n")
+	if len(r.Findings) == 0 {
+		b.WriteString("No findings.\n")
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "- **%s**: %s\n", f.Kind, f.Message)
+	}
+	for _, t := range r.Tables {
+		fmt.Fprintf(&b, "\n## `%s`\n\n", t.Table)
+		switch t.ReadFrom {
+		case "":
+			b.WriteString("No read ACL on this table or the tables it extends.\n\n")
+		case t.Table:
+		default:
+			fmt.Fprintf(&b, "Read access is controlled by the ACLs of `%s`.\n\n", t.ReadFrom)
+		}
+		if len(t.Rules) == 0 {
+			b.WriteString("No ACLs of its own.\n")
+			continue
+		}
+		b.WriteString("| Field | Operation | Roles | Condition | Flags |\n| --- | --- | --- | --- | --- |\n")
+		for _, a := range t.Rules {
+			field := "(record)"
+			if a.Field != "" {
+				field = "`" + a.Field + "`"
+			}
+			var flags string
+			if a.Public() {
+				flags = "public"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", field, a.Operation, output.MarkdownCell(strings.Join(a.Roles, ", ")), output.MarkdownCell(condition(a)), flags)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,67 @@
+package acl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"sncli/internal/snow"
+)
+
+func TestNew(t *testing.T) {
+	tables := []snow.Table{
+		{Name: "u_order", Hierarchy: []string{"u_order"}},
+		{Name: "u_incident", Hierarchy: []string{"u_incident", "task"}},
+		{Name: "u_customer", Hierarchy: []string{"u_customer"}},
+	}
+	acls := []snow.ACL{
+		{Name: "task", Table: "task", Operation: "read", Roles: []string{"itil"}},
+		{Name: "u_customer.u_email", Table: "u_customer", Field: "u_email", Operation: "read", Roles: []string{"public"}},
+		{Name: "u_order", Table: "u_order", Operation: "read", Roles: []string{"x_app.user"}, Condition: "active=true", Scripted: true},
+		// A field rule is no read ACL on whole records
+		{Name: "u_order.*", Table: "u_order", Field: "*", Operation: "read", Roles: []string{}},
+	}
+	r := New("x_app", tables, acls)
+
+	var read []string
+	for _, ta := range r.Tables {
+		read = append(read, ta.Table+"="+ta.ReadFrom)
+	}
+	if got := strings.Join(read, ","); got != "u_customer=,u_incident=task,u_order=u_order" {
+		t.Errorf("read from = %s", got)
+	}
+	if len(r.Findings) != 2 || r.Findings[0].Kind != NoReadACL || r.Findings[0].Table != "u_customer" ||
+		r.Findings[1].Kind != PublicAccess || r.Findings[1].Field != "u_email" {
+		t.Errorf("findings = %+v", r.Findings)
+	}
+
+	rows := r.Rows()
+	want := [][]string{
+		{"u_customer", "", "read", "", "", "no read ACL"},
+		{"u_customer", "u_email", "read", "public", "", "public"},
+		{"u_order", "", "read", "x_app.user", "active=true; script", ""},
+		{"u_order", "*", "read", "", "", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("rows = %v", rows)
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d = %v, want %v", i, rows[i], want[i])
+		}
+	}
+
+	var md bytes.Buffer
+	if err := r.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"- **no_read_acl**: u_customer has no read ACL on it or the tables it extends\n",
+		"## `u_incident`\n\nRead access is controlled by the ACLs of `task`.\n\nNo ACLs of its own.\n",
+		"| (record) | read | x_app.user | active=true; script |  |\n",
+	} {
+		if !strings.Contains(md.String(), s) {
+			t.Errorf("markdown lacks %q:\n%s", s, md.String())
+		}
+	}
+}
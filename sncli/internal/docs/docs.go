@@ -0,0 +1,185 @@
+// Package docs generates a static data dictionary site, in HTML and
+// Markdown, from table schemas.
+package docs
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"sncli/internal/erd"
+	"sncli/internal/output"
+	"sncli/internal/snow"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Site is what the data dictionary documents.
+type Site struct {
+	Title         string
+	Instance      string
+	Tables        []snow.Table
+	Relationships []snow.RelationshipInfo
+}
+
+// page is the view of one table.
+type page struct {
+	Site      *Site
+	Table     snow.Table
+	Own       []snow.TableField
+	Inherited []fieldGroup
+	Choices   []snow.TableField
+	Outgoing  []snow.RelationshipInfo
+	Incoming  []snow.RelationshipInfo
+	Diagram   string
+}
+
+// fieldGroup is the fields inherited from one ancestor.
+type fieldGroup struct {
+	Table  string
+	Fields []snow.TableField
+}
+
+// executor is what html/template and text/template templates share.
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data any) error
+}
+
+// searchEntry is what the index page searches for a table.
+type searchEntry struct {
+	Name   string `json:"name"`
+	Label  string `json:"label"`
+	Fields string `json:"fields"`
+}
+
+// Generate writes the site to dir: index.html and index.md listing every
+// table, and tables/<name>.html and tables/<name>.md for each table.
+func Generate(dir string, site Site) error {
+	funcs := map[string]any{
+		"documented": site.documented,
+		"mdCell":     output.MarkdownCell,
+	}
+	html, err := htmltemplate.New("").Funcs(funcs).ParseFS(templates, "templates/*.html", "templates/*.css")
+	if err != nil {
+		return err
+	}
+	md, err := texttemplate.New("").Funcs(funcs).ParseFS(templates, "templates/*.md")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "tables"), 0755); err != nil {
+		return err
+	}
+
+	tables := append([]snow.Table(nil), site.Tables...)
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	index := make([]searchEntry, len(tables))
+	for i, t := range tables {
+		var fields []string
+		for _, f := range t.Fields {
+			fields = append(fields, f.Name, f.Label)
+		}
+		index[i] = searchEntry{Name: t.Name, Label: t.Label, Fields: strings.ToLower(strings.Join(fields, " "))}
+	}
+	search, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	data := map[string]any{
+		"Site":   &site,
+		"Tables": tables,
+		// Marshalled JSON escapes <, > and &, so it is safe inside a script
+		"Search": htmltemplate.JS(search),
+	}
+
+	write := func(path, name string, tmpl executor, data any) error {
+		var buf bytes.Buffer
+		if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, path), buf.Bytes(), 0644)
+	}
+	if err := write("index.html", "index.html", html, data); err != nil {
+		return err
+	}
+	if err := write("index.md", "index.md", md, data); err != nil {
+		return err
+	}
+	if err := write("style.css", "style.css", html, nil); err != nil {
+		return err
+	}
+	for _, t := range tables {
+		p, err := site.newPage(t)
+		if err != nil {
+			return err
+		}
+		if err := write(filepath.Join("tables", t.Name+".html"), "table.html", html, p); err != nil {
+			return err
+		}
+		if err := write(filepath.Join("tables", t.Name+".md"), "table.md", md, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Site) newPage(t snow.Table) (*page, error) {
+	p := &page{Site: s, Table: t}
+	groups := map[string]int{}
+	for _, f := range t.Fields {
+		if len(f.Choices) > 0 {
+			p.Choices = append(p.Choices, f)
+		}
+		if !f.Inherited {
+			p.Own = append(p.Own, f)
+			continue
+		}
+		i, ok := groups[f.DefinedOn]
+		if !ok {
+			i = len(p.Inherited)
+			groups[f.DefinedOn] = i
+			p.Inherited = append(p.Inherited, fieldGroup{Table: f.DefinedOn})
+		}
+		p.Inherited[i].Fields = append(p.Inherited[i].Fields, f)
+	}
+
+	var related []snow.RelationshipInfo
+	for _, rel := range s.Relationships {
+		if rel.SourceTable == t.Name {
+			p.Outgoing = append(p.Outgoing, rel)
+		}
+		if rel.TargetTable == t.Name && rel.SourceTable != t.Name {
+			p.Incoming = append(p.Incoming, rel)
+		}
+		if rel.SourceTable == t.Name || rel.TargetTable == t.Name {
+			related = append(related, rel)
+		}
+	}
+
+	// The diagram shows the table's own fields and its direct neighbours
+	var diagram bytes.Buffer
+	if err := erd.Write(&diagram, erd.Mermaid, erd.Schema{Tables: []snow.Table{t}, Relationships: related}); err != nil {
+		return nil, err
+	}
+	p.Diagram = diagram.String()
+	return p, nil
+}
+
+// documented reports whether the site has a page for table.
+func (s *Site) documented(table string) bool {
+	for _, t := range s.Tables {
+		if t.Name == table {
+			return true
+		}
+	}
+	return false
+}
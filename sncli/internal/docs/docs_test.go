@@ -0,0 +1,83 @@
+package docs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sncli/internal/snow"
+)
+
+func TestGenerate(t *testing.T) {
+	site := Site{
+		Title:    "x_app",
+		Instance: "https://dev.service-now.com",
+		Tables: []snow.Table{
+			{Name: "u_order", Label: "Order <main>", Scope: "x_app", Hierarchy: []string{"u_order", "task"}, Fields: []snow.TableField{
+				{Name: "u_customer", Label: "Customer", Type: "reference", Length: 32, Reference: "u_customer", IsMandatory: true,
+					ReferenceQualifier: "active=true", Attributes: map[string]string{"ref_auto_completer": "AJAXTableCompleter"}},
+				{Name: "u_state", Label: "State", Type: "integer", DefaultValue: "1", DependentOn: "u_type",
+					Choices: []snow.Choice{{Value: "1", Label: "New | open", DependentValue: "retail"}}},
+				{Name: "number", Label: "Number", Type: "string", DefinedOn: "task", Inherited: true},
+			}},
+			{Name: "u_customer", Label: "Customer", Hierarchy: []string{"u_customer"}},
+		},
+		Relationships: []snow.RelationshipInfo{
+			{SourceTable: "u_order", TargetTable: "u_customer", Field: "u_customer", Type: snow.Reference, Cardinality: "N:1"},
+			{SourceTable: "u_order", TargetTable: "task", Field: "super_class", Type: snow.Inheritance, Cardinality: "1:1"},
+		},
+	}
+	dir := t.TempDir()
+	if err := Generate(dir, site); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	checks := map[string][]string{
+		"index.html": {
+			`<a href="tables/u_customer.html"><code>u_customer</code></a>`,
+			`Order &lt;main&gt;`,
+			`const index = [{"name":"u_customer"`,
+			`"fields":"u_customer customer u_state state number number"`,
+		},
+		"index.md": {"| [`u_order`](tables/u_order.md) | Order <main> |"},
+		"tables/u_order.html": {
+			`Hierarchy <a href="u_order.html"><code>u_order</code></a> → <code>task</code> → (base)`,
+			`<td><a href="u_customer.html"><code>u_customer</code></a></td><td><div>Qualifier <code>active=true</code></div><div>Attributes <code>ref_auto_completer=AJAXTableCompleter</code></div></td></tr>`,
+			`<td><div>Default <code>1</code></div><div>Depends on <code>u_type</code></div></td></tr>`,
+			`<h3>Inherited from <code>task</code></h3>`,
+			`<th>When <code>u_type</code> is</th>`,
+			`<tr><td><code>1</code></td><td>New | open</td><td><code>retail</code></td></tr>`,
+			`<pre class="mermaid">`,
+			`u_order }o--|| u_customer : &#34;u_customer&#34;`,
+		},
+		"tables/u_order.md": {
+			"| `u_customer` | Customer | reference | 32 | yes |  | [`u_customer`](u_customer.md) | Qualifier `active=true`<br>Attributes `ref_auto_completer=AJAXTableCompleter` |",
+			"| `u_state` | State | integer |  |  |  |  | Default `1`<br>Depends on `u_type` |",
+			"### Inherited from `task`",
+			"| Value | Label | When `u_type` is |\n| --- | --- | --- |\n| `1` | New \\| open | `retail` |",
+			"```mermaid\nerDiagram\n",
+		},
+		"tables/u_customer.md": {
+			"| reference | [`u_order`](u_order.md) | `u_customer` | N:1 |  |",
+			"No fields of its own.",
+		},
+		"style.css": {"body {"},
+	}
+	for name, wants := range checks {
+		got := read(name)
+		for _, want := range wants {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s lacks %q:\n%s", name, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package erd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"sncli/internal/snow"
+)
+
+// writeDBML renders m as DBML for dbdiagram.io and dbdocs. Every reference
+// points at the sys_id of the target; child tables share the sys_id of the
+// records they extend, so inheritance is a one-to-one sys_id reference.
+func writeDBML(w io.Writer, m *model) error {
+	b := bufio.NewWriter(w)
+	for i, e := range m.entities {
+		if i > 0 {
+			fmt.Fprintln(b)
+		}
+		header := "Table " + dbmlName(e.name)
+		switch {
+		case e.external:
+			header += " [note: 'external']"
+		case e.label != "":
+			header += fmt.Sprintf(" [note: %s]", dbmlString(e.label))
+		}
+		fmt.Fprintf(b, "%s {\n", header)
+		for _, c := range e.columns {
+			var settings []string
+			if c.key {
+				settings = append(settings, "pk")
+			} else {
+				if c.mandatory {
+					settings = append(settings, "not null")
+				}
+				if c.unique {
+					settings = append(settings, "unique")
+				}
+			}
+			if c.defaultValue != "" {
+				settings = append(settings, "default: "+dbmlString(c.defaultValue))
+			}
+			note := c.notes
+			if c.label != "" {
+				note = append([]string{c.label}, note...)
+			}
+			if len(note) > 0 {
+				settings = append(settings, "note: "+dbmlString(strings.Join(note, "; ")))
+			}
+			line := dbmlName(c.name) + " " + dbmlName(c.typ)
+			if len(settings) > 0 {
+				line += " [" + strings.Join(settings, ", ") + "]"
+			}
+			fmt.Fprintf(b, "  %s\n", line)
+		}
+		fmt.Fprintln(b, "}")
+	}
+
+	seen := map[string]bool{}
+	for _, e := range m.edges {
+		from := e.field
+		if from == "" {
+			from = "sys_id"
+		}
+		ref := fmt.Sprintf("Ref: %s.%s %s %s.sys_id", dbmlName(e.from), dbmlName(from), dbmlOperator(e), dbmlName(e.to))
+		if seen[ref] {
+			continue
+		}
+		if len(seen) == 0 {
+			fmt.Fprintln(b)
+		}
+		seen[ref] = true
+		if e.label != "" {
+			ref += " // " + e.label
+		}
+		fmt.Fprintln(b, ref)
+	}
+	return b.Flush()
+}
+
+// dbmlOperator returns the DBML relationship operator for an edge.
+func dbmlOperator(e edge) string {
+	if e.kind == snow.Inheritance {
+		return "-"
+	}
+	switch e.cardinality {
+	case "1:1":
+		return "-"
+	case "1:N":
+		return "<"
+	case "N:M":
+		return "<>"
+	}
+	return ">"
+}
+
+// dbmlName quotes a name DBML would not read as a plain identifier.
+func dbmlName(s string) string {
+	for _, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+		}
+	}
+	return s
+}
+
+// dbmlString returns s as a single-quoted DBML string.
+func dbmlString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,66 @@
+package erd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"sncli/internal/snow"
+)
+
+// writeDOT renders m as a Graphviz digraph with one record node per
+// entity. Edges carry their cardinality as tail and head labels.
+func writeDOT(w io.Writer, m *model) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph schema {")
+	fmt.Fprintln(b, "  rankdir=LR;")
+	fmt.Fprintln(b, `  node [shape=record, fontname="Helvetica", fontsize=10];`)
+	fmt.Fprintln(b, `  edge [fontname="Helvetica", fontsize=9];`)
+	for _, e := range m.entities {
+		title := dotRecord(e.name)
+		if e.label != "" {
+			title += `\n` + dotRecord(e.label)
+		}
+		var rows strings.Builder
+		for _, c := range e.columns {
+			row := c.name + " : " + c.typ
+			switch {
+			case c.key:
+				row += " (PK)"
+			case c.foreign:
+				row += " (FK)"
+			}
+			if details := c.details(); len(details) > 0 {
+				row += " [" + strings.Join(details, "; ") + "]"
+			}
+			rows.WriteString(dotRecord(row) + `\l`)
+		}
+		style := ""
+		if e.external {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(b, "  %q [label=\"{%s|%s}\"%s];\n", e.name, title, rows.String(), style)
+	}
+	for _, e := range m.edges {
+		var attrs string
+		switch e.kind {
+		case snow.Inheritance:
+			attrs = `arrowhead=empty, style=dashed, label="extends"`
+		default:
+			from, to := ends(e.cardinality)
+			attrs = fmt.Sprintf(`label="%s", taillabel="%s", headlabel="%s"`, dotRecord(e.label), from, to)
+			if e.kind == snow.ManyToMany || e.kind == snow.GlideList {
+				attrs += ", dir=both"
+			}
+		}
+		fmt.Fprintf(b, "  %q -> %q [%s];\n", e.from, e.to, attrs)
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// dotRecord escapes the characters with a meaning in record labels.
+func dotRecord(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`).Replace(s)
+}
@@ -0,0 +1,219 @@
+package erd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"sncli/internal/snow"
+)
+
+// Sizes of the draw.io layout, in points.
+const (
+	drawioWidth     = 260
+	drawioHeader    = 30
+	drawioRow       = 26
+	drawioColumnGap = 120
+	drawioRowGap    = 40
+)
+
+const (
+	drawioEntityStyle = "swimlane;fontStyle=1;childLayout=stackLayout;horizontal=1;startSize=30;horizontalStack=0;" +
+		"resizeParent=1;resizeParentMax=0;resizeLast=0;collapsible=1;marginBottom=0;html=0;"
+	drawioExternalStyle = drawioEntityStyle + "dashed=1;fillColor=#f5f5f5;"
+	drawioColumnStyle   = "text;strokeColor=none;fillColor=none;align=left;verticalAlign=middle;spacingLeft=4;" +
+		"spacingRight=4;overflow=hidden;rotatable=0;points=[[0,0.5],[1,0.5]];portConstraint=eastwest;html=0;"
+	drawioEdgeStyle        = "edgeStyle=entityRelationEdgeStyle;html=0;endFill=0;startFill=0;"
+	drawioInheritanceStyle = "edgeStyle=orthogonalEdgeStyle;html=0;endArrow=block;endFill=0;dashed=1;"
+)
+
+type drawioFile struct {
+	XMLName xml.Name      `xml:"mxfile"`
+	Host    string        `xml:"host,attr"`
+	Diagram drawioDiagram `xml:"diagram"`
+}
+
+type drawioDiagram struct {
+	ID    string      `xml:"id,attr"`
+	Name  string      `xml:"name,attr"`
+	Model drawioModel `xml:"mxGraphModel"`
+}
+
+type drawioModel struct {
+	Grid      int          `xml:"grid,attr"`
+	GridSize  int          `xml:"gridSize,attr"`
+	Guides    int          `xml:"guides,attr"`
+	Connect   int          `xml:"connect,attr"`
+	Arrows    int          `xml:"arrows,attr"`
+	PageWidth int          `xml:"pageWidth,attr"`
+	Cells     []drawioCell `xml:"root>mxCell"`
+}
+
+type drawioCell struct {
+	ID       string          `xml:"id,attr"`
+	Value    string          `xml:"value,attr,omitempty"`
+	Style    string          `xml:"style,attr,omitempty"`
+	Parent   string          `xml:"parent,attr,omitempty"`
+	Vertex   string          `xml:"vertex,attr,omitempty"`
+	Edge     string          `xml:"edge,attr,omitempty"`
+	Source   string          `xml:"source,attr,omitempty"`
+	Target   string          `xml:"target,attr,omitempty"`
+	Geometry *drawioGeometry `xml:"mxGeometry"`
+}
+
+type drawioGeometry struct {
+	X        int    `xml:"x,attr,omitempty"`
+	Y        int    `xml:"y,attr,omitempty"`
+	Width    int    `xml:"width,attr,omitempty"`
+	Height   int    `xml:"height,attr,omitempty"`
+	Relative string `xml:"relative,attr,omitempty"`
+	As       string `xml:"as,attr"`
+}
+
+// writeDrawIO renders m as an uncompressed draw.io diagram. Entities are
+// laid out in columns so that relationships point from left to right
+// where the graph allows it.
+func writeDrawIO(w io.Writer, name string, m *model) error {
+	if name == "" {
+		name = "Schema"
+	}
+	file := drawioFile{Host: "sncli", Diagram: drawioDiagram{ID: "schema", Name: name}}
+	cells := []drawioCell{{ID: "0"}, {ID: "1", Parent: "0"}}
+
+	ids := map[string]string{}
+	heights := map[int]int{}
+	levels := layers(m)
+	for i, e := range m.entities {
+		id := fmt.Sprintf("e%d", i)
+		ids[e.name] = id
+		level := levels[e.name]
+		height := drawioHeader + drawioRow*len(e.columns)
+		style := drawioEntityStyle
+		if e.external {
+			style = drawioExternalStyle
+		}
+		title := e.name
+		if e.label != "" {
+			title = e.label + " (" + e.name + ")"
+		}
+		cells = append(cells, drawioCell{
+			ID: id, Value: title, Style: style, Parent: "1", Vertex: "1",
+			Geometry: &drawioGeometry{
+				X:      level * (drawioWidth + drawioColumnGap),
+				Y:      heights[level],
+				Width:  drawioWidth,
+				Height: height,
+				As:     "geometry",
+			},
+		})
+		heights[level] += height + drawioRowGap
+
+		for j, c := range e.columns {
+			value := c.name + " : " + c.typ
+			switch {
+			case c.key:
+				value = "PK " + value
+			case c.foreign:
+				value = "FK " + value
+			}
+			if details := c.details(); len(details) > 0 {
+				value += " [" + strings.Join(details, "; ") + "]"
+			}
+			cells = append(cells, drawioCell{
+				ID: fmt.Sprintf("%s-c%d", id, j), Value: value, Style: drawioColumnStyle, Parent: id, Vertex: "1",
+				Geometry: &drawioGeometry{
+					Y:      drawioHeader + j*drawioRow,
+					Width:  drawioWidth,
+					Height: drawioRow,
+					As:     "geometry",
+				},
+			})
+		}
+	}
+
+	for i, e := range m.edges {
+		style := drawioInheritanceStyle
+		value := ""
+		if e.kind != snow.Inheritance {
+			from, to := ends(e.cardinality)
+			style = drawioEdgeStyle + "startArrow=" + drawioArrow(from, false) + ";endArrow=" + drawioArrow(to, true) + ";"
+			value = e.label
+		}
+		cells = append(cells, drawioCell{
+			ID: fmt.Sprintf("r%d", i), Value: value, Style: style, Parent: "1", Edge: "1",
+			Source: ids[e.from], Target: ids[e.to],
+			Geometry: &drawioGeometry{Relative: "1", As: "geometry"},
+		})
+	}
+
+	file.Diagram.Model = drawioModel{Grid: 1, GridSize: 10, Guides: 1, Connect: 1, Arrows: 1, PageWidth: 1169, Cells: cells}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(file); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// drawioArrow returns the ER arrow for one end of a relationship. The
+// referenced end of a reference is mandatory, the referencing end is not.
+func drawioArrow(end string, target bool) string {
+	switch {
+	case end != "1":
+		return "ERzeroToMany"
+	case target:
+		return "ERmandOne"
+	}
+	return "ERzeroToOne"
+}
+
+// layers assigns each entity a column: the longest path leading to it,
+// ignoring the edges that close cycles.
+func layers(m *model) map[string]int {
+	next := map[string][]string{}
+	for _, e := range m.edges {
+		if e.from != e.to {
+			next[e.from] = append(next[e.from], e.to)
+		}
+	}
+
+	// Reverse DFS postorder is a topological order once back edges are
+	// dropped, and back edges are exactly those pointing earlier in it.
+	visited := map[string]bool{}
+	var order []string
+	var visit func(name string)
+	visit = func(name string) {
+		visited[name] = true
+		for _, to := range next[name] {
+			if !visited[to] {
+				visit(to)
+			}
+		}
+		order = append(order, name)
+	}
+	for _, e := range m.entities {
+		if !visited[e.name] {
+			visit(e.name)
+		}
+	}
+	position := map[string]int{}
+	for i, name := range order {
+		position[name] = len(order) - 1 - i
+	}
+
+	levels := map[string]int{}
+	for i := len(order) - 1; i >= 0; i-- {
+		from := order[i]
+		for _, to := range next[from] {
+			if position[to] > position[from] {
+				levels[to] = max(levels[to], levels[from]+1)
+			}
+		}
+	}
+	return levels
+}
@@ -0,0 +1,304 @@
+// Package erd renders table schemas and their relationships as entity
+// relationship diagrams for diagram tools.
+package erd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sncli/internal/snow"
+)
+
+// Format is a diagram format.
+type Format string
+
+const (
+	Mermaid  Format = "mermaid"
+	PlantUML Format = "plantuml"
+	DOT      Format = "dot"
+	DBML     Format = "dbml"
+	Lucid    Format = "lucid"
+	DrawIO   Format = "drawio"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{Mermaid, PlantUML, DOT, DBML, Lucid, DrawIO}
+
+// ParseFormat parses a diagram format name.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if Format(strings.ToLower(s)) == f {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown diagram format %q (want mermaid, plantuml, dot, dbml, lucid or drawio)", s)
+}
+
+// Ext is the usual file extension of f.
+func (f Format) Ext() string {
+	switch f {
+	case Mermaid:
+		return "mmd"
+	case PlantUML:
+		return "puml"
+	case Lucid:
+		return "csv"
+	}
+	return string(f)
+}
+
+// Schema is what a diagram shows.
+type Schema struct {
+	// Name names the diagram and, in Lucidchart imports, the database
+	// schema holding every table.
+	Name          string
+	Tables        []snow.Table
+	Relationships []snow.RelationshipInfo
+}
+
+// Write renders s as a diagram in format f.
+func Write(w io.Writer, f Format, s Schema) error {
+	m := newModel(s)
+	switch f {
+	case Mermaid:
+		return writeMermaid(w, m)
+	case PlantUML:
+		return writePlantUML(w, m)
+	case DOT:
+		return writeDOT(w, m)
+	case DBML:
+		return writeDBML(w, m)
+	case Lucid:
+		return writeLucid(w, s.Name, m)
+	case DrawIO:
+		return writeDrawIO(w, s.Name, m)
+	}
+	return fmt.Errorf("unknown diagram format %q", f)
+}
+
+// anyTable names the entity standing for every table a document_id field
+// may point at. Table names are lowercase, so it cannot clash with one.
+const anyTable = "ANY_TABLE"
+
+// model is the diagram-neutral form of a schema.
+type model struct {
+	entities []entity
+	edges    []edge
+}
+
+// entity is a table of the diagram. External entities are tables outside
+// the export that relationships point at; they only show their key.
+type entity struct {
+	name     string
+	label    string
+	columns  []column
+	external bool
+}
+
+type column struct {
+	name   string
+	typ    string
+	label  string
+	length int
+	// references is the table a foreign key points at.
+	references string
+	key        bool
+	foreign    bool
+	mandatory  bool
+	unique     bool
+	// defaultValue and notes carry the dictionary details of the field
+	// that diagrams have no dedicated notation for.
+	defaultValue string
+	notes        []string
+}
+
+// details returns the default value and notes of c as one list.
+func (c column) details() []string {
+	if c.defaultValue == "" {
+		return c.notes
+	}
+	return append([]string{"default: " + c.defaultValue}, c.notes...)
+}
+
+// edge is a relationship between two entities. Field is the source column
+// holding the reference, empty for relationships between whole tables.
+type edge struct {
+	from, to    string
+	field       string
+	kind        snow.RelationshipKind
+	cardinality string
+	label       string
+}
+
+func newModel(s Schema) *model {
+	m := &model{}
+	known := map[string]bool{}
+	for _, t := range s.Tables {
+		known[t.Name] = true
+	}
+
+	for _, t := range s.Tables {
+		e := entity{name: t.Name, label: t.Label}
+		e.columns = append(e.columns, column{name: "sys_id", typ: "GUID", key: true, mandatory: true, unique: true})
+		has := map[string]bool{"sys_id": true}
+		for _, f := range t.Fields {
+			// Inherited fields are shown on the table defining them
+			if f.Inherited || has[f.Name] {
+				continue
+			}
+			has[f.Name] = true
+			e.columns = append(e.columns, column{
+				name:       f.Name,
+				typ:        typeName(f.Type),
+				label:      f.Label,
+				length:     f.Length,
+				references: references(f),
+				foreign:    references(f) != "",
+				mandatory:  f.IsMandatory,
+				unique:     f.IsUnique,
+
+				defaultValue: f.DefaultValue,
+				notes:        fieldNotes(f),
+			})
+		}
+		// Without field details the reference columns still need to exist
+		for _, rel := range s.Relationships {
+			if rel.SourceTable != t.Name || !fieldKind(rel.Type) || has[rel.Field] {
+				continue
+			}
+			has[rel.Field] = true
+			e.columns = append(e.columns, column{
+				name:       rel.Field,
+				typ:        string(rel.Type),
+				label:      rel.Label,
+				references: entityName(rel.TargetTable),
+				foreign:    true,
+			})
+		}
+		m.entities = append(m.entities, e)
+	}
+
+	var external []string
+	addExternal := func(name string) {
+		if !known[name] {
+			known[name] = true
+			external = append(external, name)
+		}
+	}
+	for _, rel := range s.Relationships {
+		to := entityName(rel.TargetTable)
+		addExternal(rel.SourceTable)
+		addExternal(to)
+		label := rel.Field
+		switch rel.Type {
+		case snow.Inheritance:
+			label = "extends"
+		case snow.ManyToMany:
+			label = "via " + rel.Via
+		case snow.RelatedList:
+			label = rel.Label
+		}
+		e := edge{from: rel.SourceTable, to: to, kind: rel.Type, cardinality: rel.Cardinality, label: label}
+		if fieldKind(rel.Type) {
+			e.field = rel.Field
+		}
+		m.edges = append(m.edges, e)
+	}
+	sort.Strings(external)
+	for _, name := range external {
+		m.entities = append(m.entities, entity{
+			name:     name,
+			columns:  []column{{name: "sys_id", typ: "GUID", key: true, mandatory: true, unique: true}},
+			external: true,
+		})
+	}
+	return m
+}
+
+// entityName returns the entity standing for table.
+func entityName(table string) string {
+	if table == snow.AnyTable {
+		return anyTable
+	}
+	return table
+}
+
+// references returns the entity a field points at, if any.
+func references(f snow.TableField) string {
+	if f.Type == string(snow.DocumentID) {
+		return anyTable
+	}
+	return f.Reference
+}
+
+// fieldNotes describes the dictionary details of a field, such as
+// "depends on: category" or "choices: 1=High, 2=Low".
+func fieldNotes(f snow.TableField) []string {
+	var notes []string
+	if f.Display {
+		notes = append(notes, "display")
+	}
+	if f.DependentOn != "" {
+		notes = append(notes, "depends on: "+f.DependentOn)
+	}
+	if f.ReferenceQualifier != "" {
+		notes = append(notes, "qualifier: "+f.ReferenceQualifier)
+	}
+	if len(f.Attributes) > 0 {
+		notes = append(notes, "attributes: "+f.AttributeString())
+	}
+	if len(f.Choices) > 0 {
+		choices := make([]string, len(f.Choices))
+		for i, c := range f.Choices {
+			choices[i] = c.Value + "=" + c.Label
+		}
+		notes = append(notes, "choices: "+strings.Join(choices, ", "))
+	}
+	return notes
+}
+
+// fieldKind reports whether relationships of kind are made by a field of
+// the source table.
+func fieldKind(kind snow.RelationshipKind) bool {
+	switch kind {
+	case snow.Reference, snow.GlideList, snow.DocumentID:
+		return true
+	}
+	return false
+}
+
+// typeName returns a field type usable as a single word.
+func typeName(t string) string {
+	if t == "" {
+		return "string"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return '_'
+		}
+		return r
+	}, t)
+}
+
+// ends splits a cardinality such as "N:1" into its source and target ends.
+func ends(cardinality string) (from, to string) {
+	from, to, _ = strings.Cut(cardinality, ":")
+	return from, to
+}
+
+// crowsFoot returns the crow's foot connector for an edge, as used by
+// Mermaid and PlantUML. Source ends may have no matching record, target
+// ends of references must exist.
+func crowsFoot(e edge) string {
+	from, to := ends(e.cardinality)
+	left, right := "|o", "||"
+	if from != "1" {
+		left = "}o"
+	}
+	if to != "1" {
+		right = "o{"
+	}
+	return left + "--" + right
+}
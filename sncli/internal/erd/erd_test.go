@@ -0,0 +1,172 @@
+package erd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"sncli/internal/snow"
+)
+
+func testSchema() Schema {
+	return Schema{
+		Tables: []snow.Table{{
+			Name:  "u_order",
+			Label: "Order",
+			Fields: []snow.TableField{
+				{Name: "u_customer", Label: "Customer", Type: "reference", Reference: "u_customer", IsMandatory: true,
+					ReferenceQualifier: "active=true"},
+				{Name: "u_total", Label: "Total", Type: "decimal", DefaultValue: "0"},
+				{Name: "u_state", Type: "integer", Choices: []snow.Choice{{Value: "1", Label: "New"}, {Value: "2", Label: "Paid"}}},
+				{Name: "number", Label: "Number", Type: "string", Inherited: true, DefinedOn: "task"},
+			},
+		}},
+		Relationships: []snow.RelationshipInfo{
+			{SourceTable: "u_order", TargetTable: "u_customer", Field: "u_customer", Type: snow.Reference, Cardinality: "N:1"},
+			{SourceTable: "u_order", TargetTable: snow.AnyTable, Field: "u_document", Type: snow.DocumentID, Via: "u_table", Cardinality: "N:1"},
+			{SourceTable: "u_order", TargetTable: "task", Field: "super_class", Type: snow.Inheritance, IsParentChild: true, Cardinality: "1:1"},
+			{SourceTable: "u_order", TargetTable: "u_product", Type: snow.ManyToMany, Via: "u_m2m_order_product", Cardinality: "N:M"},
+		},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   []string
+		absent []string
+	}{
+		{Mermaid, []string{
+			"erDiagram\n    %% Order\n    u_order {\n        GUID sys_id PK\n        reference u_customer FK \"Customer; qualifier: active=true\"\n        decimal u_total \"Total; default: 0\"\n        integer u_state \"choices: 1=New, 2=Paid\"\n        document_id u_document FK\n    }\n",
+			"    u_order }o--|| u_customer : \"u_customer\"\n",
+			"    u_order }o--|| ANY_TABLE : \"u_document\"\n",
+			"    u_order |o--|| task : \"extends\"\n",
+			"    u_order }o--o{ u_product : \"via u_m2m_order_product\"\n",
+			"    %% task (external)\n    task {\n        GUID sys_id PK\n    }\n",
+		}, []string{"number"}},
+		{PlantUML, []string{
+			"@startuml\n",
+			"entity \"Order\\nu_order\" as u_order {\n  * sys_id : GUID <<PK>>\n  --\n  * u_customer : reference <<FK>> [qualifier: active=true]\n  u_total : decimal [default: 0]\n",
+			"entity \"task\" as task <<external>> ##[dashed] {\n  * sys_id : GUID <<PK>>\n}\n",
+			"u_order --|> task\n",
+			"u_order }o--|| u_customer : u_customer\n",
+			"@enduml\n",
+		}, nil},
+		{DOT, []string{
+			"  \"u_order\" [label=\"{u_order\\nOrder|sys_id : GUID (PK)\\lu_customer : reference (FK) [qualifier: active=true]\\lu_total : decimal [default: 0]\\lu_state : integer [choices: 1=New, 2=Paid]\\lu_document : document_id (FK)\\l}\"];\n",
+			"  \"task\" [label=\"{task|sys_id : GUID (PK)\\l}\", style=dashed];\n",
+			"  \"u_order\" -> \"u_customer\" [label=\"u_customer\", taillabel=\"N\", headlabel=\"1\"];\n",
+			"  \"u_order\" -> \"task\" [arrowhead=empty, style=dashed, label=\"extends\"];\n",
+			"  \"u_order\" -> \"u_product\" [label=\"via u_m2m_order_product\", taillabel=\"N\", headlabel=\"M\", dir=both];\n",
+		}, nil},
+		{DBML, []string{
+			"Table u_order [note: 'Order'] {\n  sys_id GUID [pk]\n  u_customer reference [not null, note: 'Customer; qualifier: active=true']\n  u_total decimal [default: '0', note: 'Total']\n",
+			"Table ANY_TABLE [note: 'external'] {\n  sys_id GUID [pk]\n}\n",
+			"Ref: u_order.u_customer > u_customer.sys_id // u_customer\n",
+			"Ref: u_order.sys_id - task.sys_id // extends\n",
+			"Ref: u_order.sys_id <> u_product.sys_id // via u_m2m_order_product\n",
+		}, nil},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := Write(&buf, tt.format, testSchema()); err != nil {
+			t.Fatalf("Write(%s): %v", tt.format, err)
+		}
+		got := buf.String()
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s output lacks %q:\n%s", tt.format, want, got)
+			}
+		}
+		for _, absent := range tt.absent {
+			if strings.Contains(got, absent) {
+				t.Errorf("%s output shows inherited field %q:\n%s", tt.format, absent, got)
+			}
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("PlantUML"); err != nil || f != PlantUML {
+		t.Errorf("ParseFormat(PlantUML) = %q, %v", f, err)
+	}
+	if _, err := ParseFormat("visio"); err == nil {
+		t.Error("ParseFormat(visio) succeeded")
+	}
+}
+
+func TestWriteLucid(t *testing.T) {
+	s := testSchema()
+	s.Name = "x_app"
+	s.Tables[0].Fields[1].Length = 20
+	var buf bytes.Buffer
+	if err := Write(&buf, Lucid, s); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"dbms,TABLE_SCHEMA,TABLE_NAME,COLUMN_NAME,ORDINAL_POSITION,DATA_TYPE,CHARACTER_MAXIMUM_LENGTH,CONSTRAINT_TYPE,REFERENCED_TABLE_SCHEMA,REFERENCED_TABLE_NAME,REFERENCED_COLUMN_NAME",
+		"mysql,x_app,u_order,sys_id,1,GUID,,PRIMARY KEY,,,",
+		"mysql,x_app,u_order,u_customer,2,reference,,FOREIGN KEY,x_app,u_customer,sys_id",
+		"mysql,x_app,u_order,u_total,3,decimal,20,,,,",
+		"mysql,x_app,u_order,u_state,4,integer,,,,,",
+		"mysql,x_app,u_order,u_document,5,document_id,,FOREIGN KEY,x_app,ANY_TABLE,sys_id",
+		"mysql,x_app,ANY_TABLE,sys_id,1,GUID,,PRIMARY KEY,,,",
+	}
+	for i, w := range want {
+		if i >= len(lines) || lines[i] != w {
+			t.Fatalf("lucid output:\n%s\nwant line %d: %s", buf.String(), i, w)
+		}
+	}
+	if len(lines) != 10 {
+		t.Errorf("got %d lines, want a header and 9 columns", len(lines))
+	}
+}
+
+func TestWriteDrawIO(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, DrawIO, testSchema()); err != nil {
+		t.Fatal(err)
+	}
+	var file drawioFile
+	if err := xml.Unmarshal(buf.Bytes(), &file); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+
+	cells := map[string]drawioCell{}
+	for _, c := range file.Diagram.Model.Cells {
+		cells[c.ID] = c
+	}
+	// u_order comes first and everything it points at sits to its right
+	order := cells["e0"]
+	if order.Value != "Order (u_order)" || order.Geometry.X != 0 {
+		t.Errorf("u_order cell = %+v", order)
+	}
+	for _, id := range []string{"e1", "e2", "e3", "e4"} {
+		if c := cells[id]; c.Geometry == nil || c.Geometry.X <= order.Geometry.X {
+			t.Errorf("entity %s = %+v, want it right of u_order", id, c)
+		}
+	}
+	if c := cells["e0-c1"]; c.Parent != "e0" || c.Value != "FK u_customer : reference [qualifier: active=true]" {
+		t.Errorf("column cell = %+v", c)
+	}
+	edge := cells["r0"]
+	if edge.Source != "e0" || !strings.Contains(edge.Style, "startArrow=ERzeroToMany;endArrow=ERmandOne;") {
+		t.Errorf("reference edge = %+v", edge)
+	}
+	if !strings.Contains(cells["r2"].Style, "endArrow=block") {
+		t.Errorf("inheritance edge = %+v", cells["r2"])
+	}
+}
+
+func TestLayersIgnoreCycles(t *testing.T) {
+	m := &model{
+		entities: []entity{{name: "a"}, {name: "b"}, {name: "c"}},
+		edges:    []edge{{from: "a", to: "b"}, {from: "b", to: "a"}, {from: "b", to: "c"}, {from: "a", to: "c"}},
+	}
+	levels := layers(m)
+	if levels["a"] != 0 || levels["b"] != 1 || levels["c"] != 2 {
+		t.Errorf("levels = %v", levels)
+	}
+}
@@ -0,0 +1,52 @@
+package erd
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// lucidHeader is the column layout of Lucidchart's database import, as
+// produced by its MySQL import query.
+var lucidHeader = []string{
+	"dbms", "TABLE_SCHEMA", "TABLE_NAME", "COLUMN_NAME", "ORDINAL_POSITION", "DATA_TYPE",
+	"CHARACTER_MAXIMUM_LENGTH", "CONSTRAINT_TYPE", "REFERENCED_TABLE_SCHEMA", "REFERENCED_TABLE_NAME",
+	"REFERENCED_COLUMN_NAME",
+}
+
+// writeLucid renders m as a Lucidchart database import with one row per
+// column. Every table is placed in one schema named after the export, and
+// foreign keys point at the sys_id of the referenced table. Relationships
+// between whole tables have no column to carry them and are left out, as
+// are defaults, choices and other dictionary details the import has no
+// column for.
+func writeLucid(w io.Writer, name string, m *model) error {
+	if name == "" {
+		name = "servicenow"
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(lucidHeader); err != nil {
+		return err
+	}
+	for _, e := range m.entities {
+		for i, c := range e.columns {
+			length := ""
+			if c.length > 0 {
+				length = strconv.Itoa(c.length)
+			}
+			row := []string{"mysql", name, e.name, c.name, strconv.Itoa(i + 1), c.typ, length, "", "", "", ""}
+			switch {
+			case c.key:
+				row[7] = "PRIMARY KEY"
+			case c.references != "":
+				row[7] = "FOREIGN KEY"
+				row[8], row[9], row[10] = name, c.references, "sys_id"
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
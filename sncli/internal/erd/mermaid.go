@@ -0,0 +1,50 @@
+package erd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// writeMermaid renders m as a Mermaid erDiagram.
+func writeMermaid(w io.Writer, m *model) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "erDiagram")
+	for _, e := range m.entities {
+		switch {
+		case e.external:
+			fmt.Fprintf(b, "    %%%% %s (external)\n", e.name)
+		case e.label != "":
+			fmt.Fprintf(b, "    %%%% %s\n", e.label)
+		}
+		fmt.Fprintf(b, "    %s {\n", e.name)
+		for _, c := range e.columns {
+			line := c.typ + " " + c.name
+			switch {
+			case c.key:
+				line += " PK"
+			case c.foreign:
+				line += " FK"
+			}
+			comment := c.details()
+			if c.label != "" {
+				comment = append([]string{c.label}, comment...)
+			}
+			if len(comment) > 0 {
+				line += ` "` + mermaidText(strings.Join(comment, "; ")) + `"`
+			}
+			fmt.Fprintf(b, "        %s\n", line)
+		}
+		fmt.Fprintln(b, "    }")
+	}
+	for _, e := range m.edges {
+		fmt.Fprintf(b, "    %s %s %s : \"%s\"\n", e.from, crowsFoot(e), e.to, mermaidText(e.label))
+	}
+	return b.Flush()
+}
+
+// mermaidText drops the double quotes Mermaid strings cannot hold.
+func mermaidText(s string) string {
+	return strings.ReplaceAll(s, `"`, "'")
+}
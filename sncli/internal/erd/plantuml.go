@@ -0,0 +1,66 @@
+package erd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"sncli/internal/snow"
+)
+
+// writePlantUML renders m as a PlantUML entity diagram in information
+// engineering notation.
+func writePlantUML(w io.Writer, m *model) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "@startuml")
+	fmt.Fprintln(b, "hide circle")
+	fmt.Fprintln(b, "skinparam linetype ortho")
+	fmt.Fprintln(b)
+	for _, e := range m.entities {
+		title := e.name
+		if e.label != "" {
+			title = e.label + "\\n" + e.name
+		}
+		stereotype := ""
+		if e.external {
+			stereotype = " <<external>> ##[dashed]"
+		}
+		fmt.Fprintf(b, "entity \"%s\" as %s%s {\n", plantText(title), e.name, stereotype)
+		for i, c := range e.columns {
+			line := c.name + " : " + c.typ
+			if c.mandatory {
+				line = "* " + line
+			}
+			switch {
+			case c.key:
+				line += " <<PK>>"
+			case c.foreign:
+				line += " <<FK>>"
+			}
+			if details := c.details(); len(details) > 0 {
+				line += " [" + strings.Join(details, "; ") + "]"
+			}
+			fmt.Fprintf(b, "  %s\n", line)
+			if i == 0 && len(e.columns) > 1 {
+				fmt.Fprintln(b, "  --")
+			}
+		}
+		fmt.Fprintln(b, "}")
+		fmt.Fprintln(b)
+	}
+	for _, e := range m.edges {
+		if e.kind == snow.Inheritance {
+			fmt.Fprintf(b, "%s --|> %s\n", e.from, e.to)
+			continue
+		}
+		fmt.Fprintf(b, "%s %s %s : %s\n", e.from, crowsFoot(e), e.to, e.label)
+	}
+	fmt.Fprintln(b, "@enduml")
+	return b.Flush()
+}
+
+// plantText makes s safe inside a PlantUML string.
+func plantText(s string) string {
+	return strings.ReplaceAll(s, `"`, "'")
+}
@@ -0,0 +1,340 @@
+// Package output renders command results and status messages in the format
+// chosen with the global --output flag.
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format.
+type Format string
+
+const (
+	Table  Format = "table"
+	JSON   Format = "json"
+	YAML   Format = "yaml"
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{Table, JSON, YAML, CSV, NDJSON}
+
+// NoColorEnv disables colored output when set to any value, as described
+// at https://no-color.org.
+const NoColorEnv = "NO_COLOR"
+
+// ParseFormat parses an --output value.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if Format(strings.ToLower(s)) == f {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q (want table, json, yaml, csv or ndjson)", s)
+}
+
+// Structured reports whether f is a machine-readable format.
+func (f Format) Structured() bool {
+	return f != Table
+}
+
+var (
+	successStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("42")).
+			Bold(true)
+	errorStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Bold(true)
+	infoStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("87"))
+	warnStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
+)
+
+// Printer writes results to Out in its Format. Success and info messages
+// go to Out as well for table output, and to Err for the structured formats
+// so they never mix with the data. Warnings and errors always go to Err.
+type Printer struct {
+	Format Format
+	Out    io.Writer
+	Err    io.Writer
+	// TTY reports whether stdout is a terminal.
+	TTY bool
+	// Color reports whether messages are styled.
+	Color bool
+}
+
+// IsTerminal reports whether f is a terminal.
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// New returns a Printer for stdout and stderr. Colors are used only when
+// stdout is a terminal, noColor is false and $NO_COLOR is unset; otherwise
+// the lipgloss styles are disabled for the whole process.
+func New(format Format, noColor bool) *Printer {
+	tty := IsTerminal(os.Stdout)
+	_, envNoColor := os.LookupEnv(NoColorEnv)
+	color := tty && !noColor && !envNoColor
+	if !color {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+	return &Printer{Format: format, Out: os.Stdout, Err: os.Stderr, TTY: tty, Color: color}
+}
+
+// To returns a copy of p that writes results to w.
+func (p *Printer) To(w io.Writer) *Printer {
+	c := *p
+	c.Out = w
+	return &c
+}
+
+// Result is what a command prints: Value is encoded as is for JSON, YAML
+// and NDJSON, while table and CSV output use Columns and Rows.
+type Result struct {
+	Value   any
+	Columns []string
+	Rows    [][]string
+	// object lays a single value out as "Column: value" lines in tables.
+	object bool
+}
+
+// Object returns the Result for a single value with the given columns and
+// cells. Table output lists it as "Column: value" lines.
+func Object(value any, columns, cells []string) Result {
+	return Result{Value: value, Columns: columns, Rows: [][]string{cells}, object: true}
+}
+
+// Records returns the Result for a list of records. Columns default to the
+// sorted union of the record keys.
+func Records(records []map[string]any, columns []string) Result {
+	if len(columns) == 0 {
+		columns = keys(records)
+	}
+	rows := make([][]string, 0, len(records))
+	for _, r := range records {
+		row := make([]string, len(columns))
+		for i, col := range columns {
+			row[i] = Cell(r[col])
+		}
+		rows = append(rows, row)
+	}
+	return Result{Value: records, Columns: columns, Rows: rows}
+}
+
+// Record returns the Result for a single record.
+func Record(record map[string]any, columns []string) Result {
+	r := Records([]map[string]any{record}, columns)
+	r.Value = record
+	r.object = true
+	return r
+}
+
+func keys(records []map[string]any) []string {
+	seen := map[string]bool{}
+	var columns []string
+	for _, r := range records {
+		for k := range r {
+			if !seen[k] {
+				seen[k] = true
+				columns = append(columns, k)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// Cell formats a record value for a table or CSV cell. Display-value
+// objects such as {"display_value": ..., "value": ...} show their display
+// value.
+func Cell(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any:
+		if dv, ok := v["display_value"]; ok {
+			return Cell(dv)
+		}
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// Print writes r to Out in the printer's format.
+func (p *Printer) Print(r Result) error {
+	switch p.Format {
+	case JSON:
+		enc := json.NewEncoder(p.Out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r.Value)
+	case NDJSON:
+		return writeNDJSON(p.Out, r.Value)
+	case YAML:
+		return writeYAML(p.Out, r.Value)
+	case CSV:
+		w := csv.NewWriter(p.Out)
+		w.Write(r.Columns)
+		w.WriteAll(r.Rows)
+		return w.Error()
+	default:
+		return p.writeTable(r)
+	}
+}
+
+// writeNDJSON writes each element of a slice value, or the value itself,
+// as one line of JSON.
+func writeNDJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return enc.Encode(v)
+	}
+	for i := 0; i < rv.Len(); i++ {
+		if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeYAML writes v as YAML. The value goes through JSON first so struct
+// json tags and field order carry over.
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle clears the flow and quoting styles the JSON input left on n.
+func blockStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+func (p *Printer) writeTable(r Result) error {
+	if r.object {
+		for i, col := range r.Columns {
+			if len(r.Rows) == 0 || r.Rows[0][i] == "" {
+				continue
+			}
+			fmt.Fprintln(p.Out, infoStyle.Render(col+": "+flatten(r.Rows[0][i])))
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(p.Out, 0, 0, 2, ' ', 0)
+	header := make([]string, len(r.Columns))
+	for i, col := range r.Columns {
+		header[i] = strings.ToUpper(col)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range r.Rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = flatten(cell)
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// flatten keeps a multi-line cell on one table row.
+func flatten(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "\n", "; ")), " ")
+}
+
+// MarkdownCell makes s safe inside a Markdown table cell.
+func MarkdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// messages is where success and info messages go.
+func (p *Printer) messages() io.Writer {
+	if p.Format.Structured() {
+		return p.Err
+	}
+	return p.Out
+}
+
+// message writes msg in style to w one line at a time, so lipgloss does
+// not pad the lines of a block to the same width.
+func (p *Printer) message(w io.Writer, style lipgloss.Style, msg string) {
+	for _, line := range strings.Split(msg, "\n") {
+		if line == "" {
+			fmt.Fprintln(w)
+			continue
+		}
+		fmt.Fprintln(w, style.Render(line))
+	}
+}
+
+// Progress shows how many of total items of a task are done on a single,
+// rewritten line of stderr. It prints nothing unless stderr is a terminal.
+func (p *Printer) Progress(task string, done, total int) {
+	if !IsTerminal(os.Stderr) || total == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\r  %s %d/%d", task, done, total)
+	if done == total {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// Success prints a success message.
+func (p *Printer) Success(format string, a ...any) {
+	p.message(p.messages(), successStyle, fmt.Sprintf(format, a...))
+}
+
+// Info prints an informational message.
+func (p *Printer) Info(format string, a ...any) {
+	p.message(p.messages(), infoStyle, fmt.Sprintf(format, a...))
+}
+
+// Warn prints a warning to Err.
+func (p *Printer) Warn(format string, a ...any) {
+	p.message(p.Err, warnStyle, "! "+fmt.Sprintf(format, a...))
+}
+
+// Error prints msg in the error style followed by err to Err.
+func (p *Printer) Error(msg string, err error) {
+	w := p.Err
+	for strings.HasPrefix(msg, "\n") {
+		fmt.Fprintln(w)
+		msg = msg[1:]
+	}
+	fmt.Fprintln(w, errorStyle.Render("✗ "+msg), err)
+}
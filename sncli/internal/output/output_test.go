@@ -0,0 +1,93 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func render(t *testing.T, format Format, r Result) string {
+	t.Helper()
+	var buf bytes.Buffer
+	p := &Printer{Format: format, Out: &buf, Err: &buf}
+	if err := p.Print(r); err != nil {
+		t.Fatalf("Print(%s): %v", format, err)
+	}
+	return buf.String()
+}
+
+func TestPrintRecords(t *testing.T) {
+	records := []map[string]any{
+		{"number": "INC001", "priority": "1", "caller_id": map[string]any{"display_value": "Abel Tuter", "value": "62826bf0"}},
+		{"number": "INC002", "priority": "2 - High\nescalated"},
+	}
+	r := Records(records, []string{"number", "priority", "caller_id"})
+
+	tests := []struct {
+		format Format
+		want   string
+	}{
+		{Table, "NUMBER  PRIORITY             CALLER_ID\nINC001  1                    Abel Tuter\nINC002  2 - High; escalated  \n"},
+		{CSV, "number,priority,caller_id\nINC001,1,Abel Tuter\nINC002,\"2 - High\nescalated\",\n"},
+		{NDJSON, `{"caller_id":{"display_value":"Abel Tuter","value":"62826bf0"},"number":"INC001","priority":"1"}` + "\n" +
+			`{"number":"INC002","priority":"2 - High\nescalated"}` + "\n"},
+	}
+	for _, tt := range tests {
+		if got := render(t, tt.format, r); got != tt.want {
+			t.Errorf("%s output:\n%q\nwant:\n%q", tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestPrintYAMLKeepsFieldOrder(t *testing.T) {
+	value := struct {
+		Name    string `json:"name"`
+		Active  string `json:"active"`
+		Count   int    `json:"count"`
+		Aliases []int  `json:"aliases"`
+	}{"incident", "true", 3, []int{1, 2}}
+
+	got := render(t, YAML, Result{Value: value})
+	want := "name: incident\nactive: \"true\"\ncount: 3\naliases:\n  - 1\n  - 2\n"
+	if got != want {
+		t.Errorf("YAML output:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestObjectTable(t *testing.T) {
+	r := Object(nil, []string{"Instance", "User", "Email"}, []string{"https://dev1.service-now.com", "admin", ""})
+	got := render(t, Table, r)
+	if want := "Instance: https://dev1.service-now.com\nUser: admin\n"; got != want {
+		t.Errorf("table output = %q, want %q", got, want)
+	}
+}
+
+func TestMessagesAvoidStructuredOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	p := &Printer{Format: JSON, Out: &stdout, Err: &stderr}
+	p.Success("✓ Done")
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), "✓ Done") {
+		t.Errorf("stdout = %q, stderr = %q; want message on stderr only", stdout.String(), stderr.String())
+	}
+}
+
+func TestErrorsGoToStderr(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	p := &Printer{Format: Table, Out: &stdout, Err: &stderr}
+	p.Info("Fetching")
+	p.Warn("plaintext secrets")
+	p.Error("Failed", errors.New("boom"))
+	if stdout.String() != "Fetching\n" || !strings.Contains(stderr.String(), "! plaintext secrets") || !strings.Contains(stderr.String(), "boom") {
+		t.Errorf("stdout = %q, stderr = %q; want only info on stdout", stdout.String(), stderr.String())
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("NDJSON"); err != nil || f != NDJSON {
+		t.Errorf("ParseFormat(NDJSON) = %q, %v", f, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("ParseFormat(xml) succeeded, want error")
+	}
+}
@@ -0,0 +1,281 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sncli/internal/snow"
+)
+
+// Diff lists what changed from one schema to another. Fields are compared
+// on the tables defining them, so a field added to a parent table shows up
+// once rather than on every child.
+type Diff struct {
+	From          string      `json:"from"`
+	To            string      `json:"to"`
+	AddedTables   []string    `json:"added_tables"`
+	RemovedTables []string    `json:"removed_tables"`
+	ChangedTables []TableDiff `json:"changed_tables"`
+}
+
+// TableDiff lists the changes to a table present on both sides.
+type TableDiff struct {
+	Table string `json:"table"`
+	// Hierarchy is set when the table extends a different chain of tables.
+	Hierarchy     *Change       `json:"hierarchy,omitempty"`
+	AddedFields   []string      `json:"added_fields,omitempty"`
+	RemovedFields []string      `json:"removed_fields,omitempty"`
+	ChangedFields []FieldChange `json:"changed_fields,omitempty"`
+}
+
+// FieldChange lists the changed properties of a field.
+type FieldChange struct {
+	Field   string   `json:"field"`
+	Changes []Change `json:"changes"`
+}
+
+// Change is a property whose value went from From to To.
+type Change struct {
+	Property string `json:"property"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// Empty reports whether nothing changed.
+func (d *Diff) Empty() bool {
+	return len(d.AddedTables) == 0 && len(d.RemovedTables) == 0 && len(d.ChangedTables) == 0
+}
+
+// Compare returns the changes from one snapshot to another. Dictionary
+// details of fields are only compared when both snapshots record them.
+func Compare(from, to *Snapshot) *Diff {
+	d := &Diff{From: from.String(), To: to.String(), AddedTables: []string{}, RemovedTables: []string{}, ChangedTables: []TableDiff{}}
+	details := from.Version >= detailsVersion && to.Version >= detailsVersion
+	old := tablesByName(from.Tables)
+	cur := tablesByName(to.Tables)
+	for _, name := range sortedKeys(cur) {
+		if _, ok := old[name]; !ok {
+			d.AddedTables = append(d.AddedTables, name)
+		}
+	}
+	for _, name := range sortedKeys(old) {
+		t, ok := cur[name]
+		if !ok {
+			d.RemovedTables = append(d.RemovedTables, name)
+			continue
+		}
+		if td := compareTable(old[name], t, details); td != nil {
+			d.ChangedTables = append(d.ChangedTables, *td)
+		}
+	}
+	return d
+}
+
+func tablesByName(tables []snow.Table) map[string]snow.Table {
+	m := make(map[string]snow.Table, len(tables))
+	for _, t := range tables {
+		m[t.Name] = t
+	}
+	return m
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compareTable returns the changes to a table, or nil.
+func compareTable(from, to snow.Table, details bool) *TableDiff {
+	td := TableDiff{Table: to.Name}
+	if a, b := from.HierarchyPath(), to.HierarchyPath(); a != b {
+		td.Hierarchy = &Change{Property: "hierarchy", From: a, To: b}
+	}
+
+	old := ownFields(from)
+	cur := ownFields(to)
+	for _, name := range sortedKeys(cur) {
+		if _, ok := old[name]; !ok {
+			td.AddedFields = append(td.AddedFields, name)
+		}
+	}
+	for _, name := range sortedKeys(old) {
+		f, ok := cur[name]
+		if !ok {
+			td.RemovedFields = append(td.RemovedFields, name)
+			continue
+		}
+		if changes := compareField(old[name], f, details); len(changes) > 0 {
+			td.ChangedFields = append(td.ChangedFields, FieldChange{Field: name, Changes: changes})
+		}
+	}
+
+	if td.Hierarchy == nil && len(td.AddedFields) == 0 && len(td.RemovedFields) == 0 && len(td.ChangedFields) == 0 {
+		return nil
+	}
+	return &td
+}
+
+// ownFields returns the fields a table defines itself.
+func ownFields(t snow.Table) map[string]snow.TableField {
+	m := map[string]snow.TableField{}
+	for _, f := range t.Fields {
+		if !f.Inherited {
+			m[f.Name] = f
+		}
+	}
+	return m
+}
+
+func compareField(from, to snow.TableField, details bool) []Change {
+	var changes []Change
+	add := func(property, a, b string) {
+		if a != b {
+			changes = append(changes, Change{Property: property, From: a, To: b})
+		}
+	}
+	add("type", from.Type, to.Type)
+	add("length", strconv.Itoa(from.Length), strconv.Itoa(to.Length))
+	add("reference", from.Reference, to.Reference)
+	if details {
+		add("mandatory", strconv.FormatBool(from.IsMandatory), strconv.FormatBool(to.IsMandatory))
+		add("unique", strconv.FormatBool(from.IsUnique), strconv.FormatBool(to.IsUnique))
+		add("display", strconv.FormatBool(from.Display), strconv.FormatBool(to.Display))
+		add("default_value", from.DefaultValue, to.DefaultValue)
+		add("dependent_on", from.DependentOn, to.DependentOn)
+		add("reference_qualifier", from.ReferenceQualifier, to.ReferenceQualifier)
+		add("attributes", from.AttributeString(), to.AttributeString())
+		add("choices", choiceList(from.Choices), choiceList(to.Choices))
+	}
+	return changes
+}
+
+// choiceList renders choices as "value=label" pairs in sequence order.
+func choiceList(choices []snow.Choice) string {
+	pairs := make([]string, len(choices))
+	for i, c := range choices {
+		pairs[i] = c.Value + "=" + c.Label
+		if c.DependentValue != "" {
+			pairs[i] += " (" + c.DependentValue + ")"
+		}
+	}
+	return strings.Join(pairs, ", ")
+}
+
+// Rows flattens d into one row per change for tabular output, with the
+// columns Table, Change, Field, Property, From and To.
+func (d *Diff) Rows() [][]string {
+	var rows [][]string
+	for _, name := range d.AddedTables {
+		rows = append(rows, []string{name, "added", "", "", "", ""})
+	}
+	for _, name := range d.RemovedTables {
+		rows = append(rows, []string{name, "removed", "", "", "", ""})
+	}
+	for _, t := range d.ChangedTables {
+		if h := t.Hierarchy; h != nil {
+			rows = append(rows, []string{t.Table, "changed", "", h.Property, h.From, h.To})
+		}
+		for _, f := range t.AddedFields {
+			rows = append(rows, []string{t.Table, "field added", f, "", "", ""})
+		}
+		for _, f := range t.RemovedFields {
+			rows = append(rows, []string{t.Table, "field removed", f, "", "", ""})
+		}
+		for _, f := range t.ChangedFields {
+			for _, c := range f.Changes {
+				rows = append(rows, []string{t.Table, "field changed", f.Field, c.Property, c.From, c.To})
+			}
+		}
+	}
+	return rows
+}
+
+// WriteText writes d for reading in a terminal.
+func (d *Diff) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", d.From, d.To)
+	if d.Empty() {
+		b.WriteString("\nNo schema changes.\n")
+	}
+	for _, name := range d.AddedTables {
+		fmt.Fprintf(&b, "\n+ table %s\n", name)
+	}
+	for _, name := range d.RemovedTables {
+		fmt.Fprintf(&b, "\n- table %s\n", name)
+	}
+	for _, t := range d.ChangedTables {
+		fmt.Fprintf(&b, "\n~ table %s\n", t.Table)
+		if h := t.Hierarchy; h != nil {
+			fmt.Fprintf(&b, "    hierarchy: %s => %s\n", h.From, h.To)
+		}
+		for _, f := range t.AddedFields {
+			fmt.Fprintf(&b, "    + field %s\n", f)
+		}
+		for _, f := range t.RemovedFields {
+			fmt.Fprintf(&b, "    - field %s\n", f)
+		}
+		for _, f := range t.ChangedFields {
+			fmt.Fprintf(&b, "    ~ field %s: %s\n", f.Field, describeChanges(f.Changes, "%s %q => %q"))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes d as a Markdown report.
+func (d *Diff) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# Schema changes\n\n")
+	fmt.Fprintf(&b, "From %s to %s.\n", d.From, d.To)
+	if d.Empty() {
+		b.WriteString("\nNo schema changes.\n")
+	}
+	list := func(title string, names []string) {
+		if len(names) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		for _, name := range names {
+			fmt.Fprintf(&b, "- `%s`\n", name)
+		}
+	}
+	list("Added tables", d.AddedTables)
+	list("Removed tables", d.RemovedTables)
+	if len(d.ChangedTables) > 0 {
+		b.WriteString("\n## Changed tables\n")
+	}
+	for _, t := range d.ChangedTables {
+		fmt.Fprintf(&b, "\n### `%s`\n\n", t.Table)
+		if h := t.Hierarchy; h != nil {
+			fmt.Fprintf(&b, "- Hierarchy changed from `%s` to `%s`\n", h.From, h.To)
+		}
+		for _, f := range t.AddedFields {
+			fmt.Fprintf(&b, "- Added field `%s`\n", f)
+		}
+		for _, f := range t.RemovedFields {
+			fmt.Fprintf(&b, "- Removed field `%s`\n", f)
+		}
+		for _, f := range t.ChangedFields {
+			fmt.Fprintf(&b, "- Changed field `%s`: %s\n", f.Field, describeChanges(f.Changes, "%s `%s` → `%s`"))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// describeChanges joins changes rendered with format, which takes the
+// property, the old value and the new value.
+func describeChanges(changes []Change, format string) string {
+	parts := make([]string, len(changes))
+	for i, c := range changes {
+		parts[i] = fmt.Sprintf(format, c.Property, c.From, c.To)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,94 @@
+// Package snapshot saves the schema of selected tables as versioned JSON
+// and compares two schemas.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"sncli/internal/snow"
+)
+
+// Version is the snapshot format written by this build. Read accepts
+// snapshots up to this version. Version 2 added the dictionary details
+// and choices of fields.
+const Version = 2
+
+// detailsVersion is the first version recording field details.
+const detailsVersion = 2
+
+// Snapshot is the schema of selected tables at one point in time.
+type Snapshot struct {
+	Version  int    `json:"version"`
+	Instance string `json:"instance"`
+	// Scope describes the selection, and is the only record of it in
+	// snapshots taken before tables could be selected other than by scope.
+	Scope         string                  `json:"scope"`
+	Selection     *snow.TableSelection    `json:"selection,omitempty"`
+	Taken         time.Time               `json:"taken"`
+	Tables        []snow.Table            `json:"tables"`
+	Relationships []snow.RelationshipInfo `json:"relationships"`
+}
+
+// New returns a snapshot of the tables picked by sel and their
+// relationships taken now.
+func New(instance string, sel snow.TableSelection, tables []snow.Table, relationships []snow.RelationshipInfo) *Snapshot {
+	return &Snapshot{
+		Version:       Version,
+		Instance:      instance,
+		Scope:         sel.String(),
+		Selection:     &sel,
+		Taken:         time.Now().UTC().Truncate(time.Second),
+		Tables:        tables,
+		Relationships: relationships,
+	}
+}
+
+// Read loads a snapshot file.
+func Read(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+	if s.Version < 1 || s.Version > Version {
+		return nil, fmt.Errorf("snapshot %s has version %d, this sncli reads versions 1 to %d", path, s.Version, Version)
+	}
+	return &s, nil
+}
+
+// TableSelection returns the selection the tables of s were picked by.
+func (s *Snapshot) TableSelection() snow.TableSelection {
+	if s.Selection != nil {
+		return *s.Selection
+	}
+	if s.Scope == "" {
+		return snow.TableSelection{}
+	}
+	return snow.TableSelection{Scopes: []string{s.Scope}}
+}
+
+// Write writes s as indented JSON.
+func (s *Snapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// String describes where and when s was taken.
+func (s *Snapshot) String() string {
+	desc := s.Instance
+	if s.Scope != "" {
+		desc += " (" + s.Scope + ")"
+	}
+	if !s.Taken.IsZero() {
+		desc += " at " + s.Taken.Format(time.RFC3339)
+	}
+	return desc
+}
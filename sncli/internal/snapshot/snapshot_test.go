@@ -0,0 +1,135 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sncli/internal/snow"
+)
+
+func schemas() (*Snapshot, *Snapshot) {
+	from := New("https://dev.service-now.com", snow.TableSelection{Scopes: []string{"x_app"}}, []snow.Table{
+		{Name: "u_order", Hierarchy: []string{"u_order"}, Fields: []snow.TableField{
+			{Name: "u_customer", Type: "string", Length: 40},
+			{Name: "u_legacy", Type: "string"},
+			{Name: "number", Type: "string", Inherited: true, DefinedOn: "task"},
+		}},
+		{Name: "u_old"},
+	}, nil)
+	to := New("https://prod.service-now.com", snow.TableSelection{Scopes: []string{"x_app"}}, []snow.Table{
+		{Name: "u_new"},
+		{Name: "u_order", Hierarchy: []string{"u_order", "task"}, Fields: []snow.TableField{
+			{Name: "u_customer", Type: "reference", Length: 32, Reference: "customer_account"},
+			{Name: "u_total", Type: "decimal"},
+			{Name: "number", Type: "string", Length: 40, Inherited: true, DefinedOn: "task"},
+		}},
+	}, nil)
+	return from, to
+}
+
+func TestCompare(t *testing.T) {
+	d := Compare(schemas())
+	if strings.Join(d.AddedTables, ",") != "u_new" || strings.Join(d.RemovedTables, ",") != "u_old" {
+		t.Errorf("added %v, removed %v", d.AddedTables, d.RemovedTables)
+	}
+	if len(d.ChangedTables) != 1 {
+		t.Fatalf("changed tables = %+v", d.ChangedTables)
+	}
+	order := d.ChangedTables[0]
+	if h := order.Hierarchy; h == nil || h.From != "u_order -> (base)" || h.To != "u_order -> task -> (base)" {
+		t.Errorf("hierarchy = %+v", h)
+	}
+	if strings.Join(order.AddedFields, ",") != "u_total" || strings.Join(order.RemovedFields, ",") != "u_legacy" {
+		t.Errorf("added fields %v, removed %v", order.AddedFields, order.RemovedFields)
+	}
+	// Inherited fields are compared on their own table, not here
+	if len(order.ChangedFields) != 1 || len(order.ChangedFields[0].Changes) != 3 {
+		t.Errorf("changed fields = %+v", order.ChangedFields)
+	}
+
+	var text, md bytes.Buffer
+	if err := d.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if want := `    ~ field u_customer: type "string" => "reference", length "40" => "32", reference "" => "customer_account"`; !strings.Contains(text.String(), want) {
+		t.Errorf("text report lacks %q:\n%s", want, text.String())
+	}
+	if err := d.WriteMarkdown(&md); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"## Added tables\n\n- `u_new`\n", "### `u_order`\n\n- Hierarchy changed from `u_order -> (base)` to `u_order -> task -> (base)`\n"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown report lacks %q:\n%s", want, md.String())
+		}
+	}
+	if rows := d.Rows(); len(rows) != 8 {
+		t.Errorf("got %d rows, want 8: %v", len(rows), rows)
+	}
+}
+
+func TestReadRejectsNewerVersions(t *testing.T) {
+	from, _ := schemas()
+	path := filepath.Join(t.TempDir(), "s.json")
+	var buf bytes.Buffer
+	if err := from.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(path)
+	if err != nil || !Compare(from, read).Empty() {
+		t.Fatalf("round trip: %v", err)
+	}
+
+	newer := strings.Replace(buf.String(), fmt.Sprintf(`"version": %d`, Version), fmt.Sprintf(`"version": %d`, Version+1), 1)
+	if err := os.WriteFile(path, []byte(newer), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(path); err == nil || !strings.Contains(err.Error(), fmt.Sprintf("version %d", Version+1)) {
+		t.Errorf("Read(version %d) error = %v", Version+1, err)
+	}
+}
+
+func TestCompareFieldDetails(t *testing.T) {
+	fields := func(f snow.TableField) *Snapshot {
+		return New("https://dev.service-now.com", snow.TableSelection{Scopes: []string{"x_app"}}, []snow.Table{{Name: "u_order", Fields: []snow.TableField{f}}}, nil)
+	}
+	from := fields(snow.TableField{Name: "u_state", Type: "integer", Choices: []snow.Choice{{Value: "1", Label: "New"}}})
+	to := fields(snow.TableField{Name: "u_state", Type: "integer", DefaultValue: "1",
+		Choices: []snow.Choice{{Value: "1", Label: "New"}, {Value: "2", Label: "Paid"}}})
+
+	d := Compare(from, to)
+	var got []string
+	for _, row := range d.Rows() {
+		got = append(got, strings.Join(row[3:], "|"))
+	}
+	if want := "default_value||1,choices|1=New|1=New, 2=Paid"; strings.Join(got, ",") != want {
+		t.Errorf("changes = %s, want %s", strings.Join(got, ","), want)
+	}
+
+	// Snapshots from before field details only compare the basics
+	from.Version = 1
+	if d := Compare(from, to); !d.Empty() {
+		t.Errorf("version 1 snapshot: %+v", d.ChangedTables)
+	}
+}
+
+func TestTableSelection(t *testing.T) {
+	var old Snapshot
+	if err := json.Unmarshal([]byte(`{"version":1,"scope":"x_app"}`), &old); err != nil {
+		t.Fatal(err)
+	}
+	if sel := old.TableSelection(); sel.String() != "x_app" || len(sel.Scopes) != 1 {
+		t.Errorf("selection of a scope snapshot = %+v", sel)
+	}
+	sel := snow.TableSelection{AllScopes: true, Extends: "task"}
+	if got := New("https://dev.service-now.com", sel, nil, nil); got.Scope != "all scopes extending task" || got.TableSelection().Extends != "task" {
+		t.Errorf("snapshot = %+v", got)
+	}
+}
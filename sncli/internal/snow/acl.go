@@ -0,0 +1,138 @@
+package snow
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// PublicRole is the role every user has, logged in or not.
+const PublicRole = "public"
+
+// ACL is an active record access control rule. Field is empty for rules
+// on whole records and "*" for rules on every field of the table.
+type ACL struct {
+	SysID          string   `json:"sys_id"`
+	Name           string   `json:"name"`
+	Table          string   `json:"table"`
+	Field          string   `json:"field,omitempty"`
+	Operation      string   `json:"operation"`
+	Roles          []string `json:"roles"`
+	Condition      string   `json:"condition,omitempty"`
+	Scripted       bool     `json:"scripted"`
+	AdminOverrides bool     `json:"admin_overrides"`
+	Description    string   `json:"description,omitempty"`
+}
+
+// Public reports whether the rule grants access to the public role.
+func (a ACL) Public() bool {
+	for _, role := range a.Roles {
+		if role == PublicRole {
+			return true
+		}
+	}
+	return false
+}
+
+// aclEntry is a sys_security_acl row as the Table API returns it
+type aclEntry struct {
+	SysID          string `json:"sys_id"`
+	Name           string `json:"name"`
+	Operation      string `json:"operation.name"`
+	Condition      string `json:"condition"`
+	Script         string `json:"script"`
+	Advanced       string `json:"advanced"`
+	AdminOverrides string `json:"admin_overrides"`
+	Description    string `json:"description"`
+}
+
+// aclRole is a sys_security_acl_role row, granting a role to an ACL
+type aclRole struct {
+	ACL  string `json:"sys_security_acl"`
+	Role string `json:"sys_user_role.name"`
+}
+
+// GetACLs returns the active record ACLs of tables and their fields with
+// the roles each requires, ordered by name and operation. Wildcard rules
+// for every table ("*" and "*.field") are not included.
+func (c *Client) GetACLs(ctx context.Context, tables []string) ([]ACL, error) {
+	var params []url.Values
+	for lo := 0; lo < len(tables); lo += inChunk {
+		names := tables[lo:min(lo+inChunk, len(tables))]
+		q := NewQuery().
+			Eq("active", "true").
+			Eq("type.name", "record").
+			Where("name", In, names...)
+		for _, name := range names {
+			q.Or("name", StartsWith, name+".")
+		}
+		p := url.Values{}
+		p.Set("sysparm_query", q.String())
+		p.Set("sysparm_fields", "sys_id,name,operation.name,condition,script,advanced,admin_overrides,description")
+		p.Set("sysparm_exclude_reference_link", "true")
+		params = append(params, p)
+	}
+	entries, err := collectEach[aclEntry](ctx, c, "acls", "sys_security_acl", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ACLs: %w", err)
+	}
+
+	var acls []ACL
+	var ids []string
+	for _, chunk := range entries {
+		for _, e := range chunk {
+			table, field, _ := strings.Cut(e.Name, ".")
+			acls = append(acls, ACL{
+				SysID:     e.SysID,
+				Name:      e.Name,
+				Table:     table,
+				Field:     field,
+				Operation: e.Operation,
+				Roles:     []string{},
+				Condition: e.Condition,
+				// Scripts only run on advanced rules
+				Scripted:       e.Advanced == "true" && strings.TrimSpace(e.Script) != "",
+				AdminOverrides: e.AdminOverrides == "true",
+				Description:    e.Description,
+			})
+			ids = append(ids, e.SysID)
+		}
+	}
+
+	params = nil
+	for lo := 0; lo < len(ids); lo += inChunk {
+		p := url.Values{}
+		p.Set("sysparm_query", NewQuery().Where("sys_security_acl", In, ids[lo:min(lo+inChunk, len(ids))]...).String())
+		p.Set("sysparm_fields", "sys_security_acl,sys_user_role.name")
+		p.Set("sysparm_exclude_reference_link", "true")
+		params = append(params, p)
+	}
+	grants, err := collectEach[aclRole](ctx, c, "acl roles", "sys_security_acl_role", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ACL roles: %w", err)
+	}
+	roles := map[string][]string{}
+	for _, chunk := range grants {
+		for _, g := range chunk {
+			if g.Role != "" {
+				roles[g.ACL] = append(roles[g.ACL], g.Role)
+			}
+		}
+	}
+	for i := range acls {
+		if r, ok := roles[acls[i].SysID]; ok {
+			sort.Strings(r)
+			acls[i].Roles = r
+		}
+	}
+
+	sort.SliceStable(acls, func(i, j int) bool {
+		if acls[i].Name != acls[j].Name {
+			return acls[i].Name < acls[j].Name
+		}
+		return acls[i].Operation < acls[j].Operation
+	})
+	return acls, nil
+}
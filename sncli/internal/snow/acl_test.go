@@ -0,0 +1,57 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetACLsAttachesRoles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("sysparm_query")
+		var result []map[string]string
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/sys_security_acl"):
+			if want := "active=true^type.name=record^nameINu_order,task^ORnameSTARTSWITHu_order.^ORnameSTARTSWITHtask."; query != want {
+				t.Errorf("ACL query = %s, want %s", query, want)
+			}
+			result = []map[string]string{
+				{"sys_id": "a2", "name": "u_order.u_total", "operation.name": "write", "advanced": "true", "script": "answer = true;"},
+				{"sys_id": "a1", "name": "u_order", "operation.name": "read", "condition": "active=true", "script": "ignored();"},
+				{"sys_id": "a3", "name": "task", "operation.name": "read", "admin_overrides": "true"},
+			}
+		case strings.HasSuffix(r.URL.Path, "/sys_security_acl_role"):
+			if query != "sys_security_aclINa2,a1,a3" {
+				t.Errorf("role query = %s", query)
+			}
+			result = []map[string]string{
+				{"sys_security_acl": "a1", "sys_user_role.name": "x_app.user"},
+				{"sys_security_acl": "a1", "sys_user_role.name": "itil"},
+				{"sys_security_acl": "a2", "sys_user_role.name": "public"},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	acls, err := c.GetACLs(context.Background(), []string{"u_order", "task"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, a := range acls {
+		got = append(got, fmt.Sprintf("%s/%s/%s/%s/%v/%v", a.Table, a.Field, a.Operation, strings.Join(a.Roles, "+"), a.Scripted, a.Public()))
+	}
+	want := "task//read//false/false,u_order//read/itil+x_app.user/false/false,u_order/u_total/write/public/true/true"
+	if strings.Join(got, ",") != want {
+		t.Errorf("acls = %s, want %s", strings.Join(got, ","), want)
+	}
+	if !acls[0].AdminOverrides || acls[1].Condition != "active=true" {
+		t.Errorf("acls = %+v", acls)
+	}
+}
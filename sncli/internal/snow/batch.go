@@ -0,0 +1,278 @@
+package snow
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const batchEndpoint = "/api/now/v1/batch"
+
+// DefaultBatchSize is the number of sub-requests sent in one Batch API call
+// when the client does not set BatchSize.
+const DefaultBatchSize = 20
+
+// ErrBatchUnsupported is returned by Batch when the instance does not offer
+// the Batch API to this user. Table helpers then fall back to individual
+// requests.
+var ErrBatchUnsupported = errors.New("batch API not available on this instance")
+
+// BatchRequest is one sub-request of a Batch API call.
+type BatchRequest struct {
+	Method string
+	// URL is the endpoint relative to the instance, as passed to Request.
+	URL string
+	// Body, if set, is sent as JSON.
+	Body any
+}
+
+// BatchResponse is the answer to one sub-request.
+type BatchResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+type batchHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type batchPayload struct {
+	BatchRequestID string            `json:"batch_request_id"`
+	RestRequests   []batchSubRequest `json:"rest_requests"`
+}
+
+type batchSubRequest struct {
+	ID      string        `json:"id"`
+	Method  string        `json:"method"`
+	URL     string        `json:"url"`
+	Headers []batchHeader `json:"headers"`
+	Body    string        `json:"body,omitempty"`
+}
+
+type batchResult struct {
+	ServicedRequests []struct {
+		ID         string        `json:"id"`
+		StatusCode int           `json:"status_code"`
+		Headers    []batchHeader `json:"headers"`
+		Body       string        `json:"body"`
+	} `json:"serviced_requests"`
+}
+
+func (c *Client) batchSize() int {
+	if c.BatchSize == 0 {
+		return DefaultBatchSize
+	}
+	return c.BatchSize
+}
+
+// batching reports whether table helpers should use the Batch API.
+func (c *Client) batching() bool {
+	return c.batchSize() > 1 && !c.noBatch.Load()
+}
+
+// Batch sends reqs in a single Batch API call and returns their responses
+// in the same order. A sub-request the instance did not service, for
+// example because the batch ran out of time, has a nil entry. When the
+// instance rejects the batch endpoint itself the error wraps
+// ErrBatchUnsupported and later table helpers stop trying it.
+func (c *Client) Batch(ctx context.Context, reqs []BatchRequest) ([]*BatchResponse, error) {
+	payload := batchPayload{BatchRequestID: "sncli", RestRequests: make([]batchSubRequest, len(reqs))}
+	for i, r := range reqs {
+		sub := &payload.RestRequests[i]
+		sub.ID = strconv.Itoa(i)
+		sub.Method = r.Method
+		sub.URL = r.URL
+		sub.Headers = []batchHeader{{"Accept", "application/json"}}
+		if r.Body != nil {
+			data, err := json.Marshal(r.Body)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal batch request %d: %w", i, err)
+			}
+			sub.Body = base64.StdEncoding.EncodeToString(data)
+			sub.Headers = append(sub.Headers, batchHeader{"Content-Type", "application/json"})
+		}
+	}
+
+	data, err := c.Request(ctx, "POST", batchEndpoint, payload)
+	if err != nil {
+		var authErr *AuthError
+		if errors.As(err, &authErr) && batchRejected(authErr.Status) {
+			c.noBatch.Store(true)
+			return nil, fmt.Errorf("%w: %v", ErrBatchUnsupported, err)
+		}
+		return nil, err
+	}
+
+	var result batchResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse batch response: %w", err)
+	}
+	responses := make([]*BatchResponse, len(reqs))
+	for _, sr := range result.ServicedRequests {
+		i, err := strconv.Atoi(sr.ID)
+		if err != nil || i < 0 || i >= len(reqs) {
+			continue
+		}
+		body, err := base64.StdEncoding.DecodeString(sr.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode batch response %d: %w", i, err)
+		}
+		header := http.Header{}
+		for _, h := range sr.Headers {
+			header.Add(h.Name, h.Value)
+		}
+		responses[i] = &BatchResponse{Status: sr.StatusCode, Header: header, Body: body}
+	}
+	return responses, nil
+}
+
+// batchRejected reports whether a batch call failing with status means the
+// endpoint is missing or closed to this user rather than a passing error.
+func batchRejected(status int) bool {
+	switch status {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound,
+		http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// collectEach runs one Table API query per params entry and returns their
+// records in the same order. Queries fresh in the cache are served from it
+// and stale ones revalidated one by one. The first pages of the rest are
+// bundled into Batch API calls of c.BatchSize sub-requests, run
+// c.Concurrency at a time; a query whose first page is full is finished
+// with a Pager. Without the Batch API each query is paged on its own.
+func collectEach[T any](ctx context.Context, c *Client, task, table string, params []url.Values) ([][]T, error) {
+	results := make([][]T, len(params))
+	single := func(ctx context.Context, i int) error {
+		records, err := collectCached[T](ctx, c, table, params[i])
+		results[i] = records
+		return err
+	}
+
+	// A job is a query fetched on its own or a batch of query indexes
+	type job struct {
+		indexes []int
+		batch   bool
+	}
+	var jobs []job
+	var misses []int
+	total := 0
+	for i := range params {
+		var entry *cacheEntry
+		if c.Cache != nil {
+			entry = c.Cache.load(table, cacheParams(params[i]))
+		}
+		switch {
+		case c.Cache.fresh(entry):
+			records, err := decodeRecords[T](entry.Records)
+			if err != nil {
+				return nil, err
+			}
+			results[i] = records
+		case entry != nil || !c.batching():
+			jobs = append(jobs, job{indexes: []int{i}})
+			total++
+		default:
+			misses = append(misses, i)
+			total++
+		}
+	}
+	if len(misses) == 1 {
+		// A lone query gains nothing from a batch
+		jobs = append(jobs, job{indexes: misses})
+		misses = nil
+	}
+	for lo := 0; lo < len(misses); lo += c.batchSize() {
+		jobs = append(jobs, job{indexes: misses[lo:min(lo+c.batchSize(), len(misses))], batch: true})
+	}
+
+	batch := func(ctx context.Context, indexes []int) error {
+		reqs := make([]BatchRequest, 0, len(indexes))
+		for _, i := range indexes {
+			p := params[i]
+			if c.Cache != nil {
+				p = cacheParams(p)
+			}
+			reqs = append(reqs, BatchRequest{Method: "GET", URL: c.NewPager(ctx, table, p).next})
+		}
+		var responses []*BatchResponse
+		if c.batching() {
+			var err error
+			responses, err = c.Batch(ctx, reqs)
+			if err != nil && !errors.Is(err, ErrBatchUnsupported) {
+				return err
+			}
+		}
+
+		for k, i := range indexes {
+			var resp *BatchResponse
+			if responses != nil {
+				resp = responses[k]
+			}
+			if resp == nil {
+				if err := single(ctx, i); err != nil {
+					return err
+				}
+				continue
+			}
+			if resp.Status < 200 || resp.Status >= 300 {
+				return &AuthError{Message: string(resp.Body), Status: resp.Status}
+			}
+
+			var page struct {
+				Result []json.RawMessage `json:"result"`
+			}
+			if err := json.Unmarshal(resp.Body, &page); err != nil {
+				return fmt.Errorf("failed to parse page: %w", err)
+			}
+			if !lastPage(resp.Header, len(page.Result), c.pageSize()) {
+				// More than one page: let a pager fetch the lot
+				if err := single(ctx, i); err != nil {
+					return err
+				}
+				continue
+			}
+			if c.Cache != nil {
+				c.Cache.store(table, cacheParams(params[i]), page.Result)
+			}
+			records, err := decodeRecords[T](page.Result)
+			if err != nil {
+				return err
+			}
+			results[i] = records
+		}
+		return nil
+	}
+
+	err := c.forEachJob(ctx, task, total, len(jobs), func(ctx context.Context, j int) (int, error) {
+		if jobs[j].batch {
+			return len(jobs[j].indexes), batch(ctx, jobs[j].indexes)
+		}
+		return 1, single(ctx, jobs[j].indexes[0])
+	})
+	return results, err
+}
+
+// lastPage reports whether a first page of n records, requested with the
+// given limit, holds every record of its query. Security rules can shorten
+// a page, so its length only decides when the instance sends no total.
+func lastPage(header http.Header, n, limit int) bool {
+	for _, link := range header.Values("Link") {
+		if linkNextRe.MatchString(link) {
+			return false
+		}
+	}
+	if total, err := strconv.Atoi(header.Get("X-Total-Count")); err == nil {
+		return total <= n
+	}
+	return n < limit
+}
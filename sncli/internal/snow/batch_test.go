@@ -0,0 +1,169 @@
+package snow
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// batchServer answers incoming reference queries for tables named
+// u_table_<n> with one reference field each and every other query with no
+// records, through the Batch API unless batch is false, in which case the
+// batch endpoint returns 400.
+func batchServer(t *testing.T, batch bool) (*httptest.Server, *[]string) {
+	t.Helper()
+	var calls []string
+	page := func(endpoint string) []byte {
+		u, _ := url.Parse(endpoint)
+		query := u.Query().Get("sysparm_query")
+		target := query[strings.LastIndex(query, "=")+1:]
+		result := []map[string]string{}
+		if strings.HasSuffix(u.Path, "/sys_dictionary") && strings.Contains(query, "^reference=") {
+			result = append(result, map[string]string{
+				"name": "u_source", "element": "ref_" + target, "internal_type": "reference", "reference": target,
+			})
+		}
+		data, _ := json.Marshal(map[string]any{"result": result})
+		return data
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.URL.Path != batchEndpoint {
+			w.Write(page(r.URL.RequestURI()))
+			return
+		}
+		if !batch {
+			http.Error(w, `{"error":{"message":"Requested URI does not represent any resource"}}`, http.StatusBadRequest)
+			return
+		}
+
+		var payload batchPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("bad batch payload: %v", err)
+		}
+		var serviced []map[string]any
+		for _, sub := range payload.RestRequests {
+			serviced = append(serviced, map[string]any{
+				"id":          sub.ID,
+				"status_code": 200,
+				"body":        base64.StdEncoding.EncodeToString(page(sub.URL)),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"batch_request_id": payload.BatchRequestID, "serviced_requests": serviced})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func relationshipTables(n int) []Table {
+	tables := make([]Table, n)
+	for i := range tables {
+		tables[i] = Table{Name: fmt.Sprintf("u_table_%d", i)}
+	}
+	return tables
+}
+
+func TestGetRelationshipsUsesBatchAPI(t *testing.T) {
+	srv, calls := batchServer(t, true)
+	c := testClient(t, srv)
+	c.Concurrency = 1
+	c.BatchSize = 4
+
+	rels, err := c.GetRelationships(context.Background(), relationshipTables(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 10 || rels[9].Field != "ref_u_table_9" {
+		t.Errorf("relationships = %+v", rels)
+	}
+	// 20 dictionary queries in 5 batches, then the m2m and related list
+	// lookups on their own
+	want := strings.Split(strings.Repeat("POST "+batchEndpoint+",", 5)+
+		"GET /api/now/table/sys_m2m,GET /api/now/table/sys_relationship", ",")
+	if strings.Join(*calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", *calls, want)
+	}
+}
+
+func TestGetRelationshipsFallsBackWithoutBatchAPI(t *testing.T) {
+	srv, calls := batchServer(t, false)
+	c := testClient(t, srv)
+	c.Concurrency = 1
+	c.BatchSize = 4
+
+	rels, err := c.GetRelationships(context.Background(), relationshipTables(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rels) != 10 {
+		t.Errorf("got %d relationships, want 10", len(rels))
+	}
+	batches := 0
+	for _, call := range *calls {
+		if call == "POST "+batchEndpoint {
+			batches++
+		}
+	}
+	if batches != 1 || len(*calls) != 23 {
+		t.Errorf("calls = %v, want one rejected batch and 22 table queries", *calls)
+	}
+}
+
+func TestCollectEachPagesShortBatchedPages(t *testing.T) {
+	// Security rules hide a record from the first page of u_b, which the
+	// instance still counts
+	records := map[string][]map[string]string{
+		"name=u_a": {{"name": "u_a"}},
+		"name=u_b": {{"name": "u_b_1"}, {"name": "u_b_3"}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != batchEndpoint {
+			json.NewEncoder(w).Encode(map[string]any{"result": records[r.URL.Query().Get("sysparm_query")]})
+			return
+		}
+		var payload batchPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("bad batch payload: %v", err)
+		}
+		var serviced []map[string]any
+		for _, sub := range payload.RestRequests {
+			u, _ := url.Parse(sub.URL)
+			query := u.Query().Get("sysparm_query")
+			result, total := records[query], len(records[query])
+			if query == "name=u_b" {
+				result, total = result[:1], 3
+			}
+			body, _ := json.Marshal(map[string]any{"result": result})
+			serviced = append(serviced, map[string]any{
+				"id":          sub.ID,
+				"status_code": 200,
+				"headers":     []map[string]string{{"name": "X-Total-Count", "value": fmt.Sprint(total)}},
+				"body":        base64.StdEncoding.EncodeToString(body),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"batch_request_id": payload.BatchRequestID, "serviced_requests": serviced})
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	var params []url.Values
+	for _, name := range []string{"u_a", "u_b"} {
+		p := url.Values{}
+		p.Set("sysparm_query", "name="+name)
+		params = append(params, p)
+	}
+	results, err := collectEach[map[string]string](context.Background(), c, "tables", "sys_db_object", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results[0]) != 1 || len(results[1]) != 2 {
+		t.Errorf("results = %v, want u_b fetched again by a pager", results)
+	}
+}
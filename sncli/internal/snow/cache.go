@@ -0,0 +1,330 @@
+package snow
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached metadata is used before it is
+// revalidated against the instance.
+const DefaultCacheTTL = 24 * time.Hour
+
+// Cache keeps Table API query results on disk under
+// ~/.sncli/cache/<instance>, one file per table and query. Entries younger
+// than TTL are used as they are. Older entries are revalidated: only
+// records whose sys_updated_on is newer than the newest cached one are
+// fetched, and the whole query is fetched again when the record count no
+// longer matches, as it does after deletions.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+	// Refresh ignores stored entries and replaces them.
+	Refresh bool
+}
+
+// cacheEntry is the stored result of one query.
+type cacheEntry struct {
+	Table   string            `json:"table"`
+	Query   string            `json:"query"`
+	Fetched time.Time         `json:"fetched"`
+	Records []json.RawMessage `json:"records"`
+}
+
+// CacheStats describes the entries stored for an instance.
+type CacheStats struct {
+	Dir     string    `json:"dir"`
+	Entries int       `json:"entries"`
+	Expired int       `json:"expired"`
+	Bytes   int64     `json:"bytes"`
+	Oldest  time.Time `json:"oldest,omitempty"`
+	Newest  time.Time `json:"newest,omitempty"`
+}
+
+// CacheRoot returns ~/.sncli/cache.
+func CacheRoot() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	return filepath.Join(homeDir, ".sncli", "cache"), nil
+}
+
+// NewCache returns the cache of user on the instance at baseURL with the
+// default TTL. Users see the records their ACLs allow, so each has its own.
+func NewCache(baseURL, user string) (*Cache, error) {
+	root, err := CacheRoot()
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid instance URL %q", baseURL)
+	}
+	if user == "" {
+		user = "_"
+	}
+	clean := strings.NewReplacer(":", "_", "/", "_", `\`, "_")
+	name := clean.Replace(u.Host + strings.TrimRight(u.Path, "/"))
+	return &Cache{Dir: filepath.Join(root, name, clean.Replace(user)), TTL: DefaultCacheTTL}, nil
+}
+
+func (c *Cache) ttl() time.Duration {
+	if c.TTL > 0 {
+		return c.TTL
+	}
+	return DefaultCacheTTL
+}
+
+func (c *Cache) path(table string, params url.Values) string {
+	sum := sha256.Sum256([]byte(table + "?" + params.Encode()))
+	return filepath.Join(c.Dir, table+"-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// load returns the stored entry for a query, or nil.
+func (c *Cache) load(table string, params url.Values) *cacheEntry {
+	if c.Refresh {
+		return nil
+	}
+	data, err := os.ReadFile(c.path(table, params))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+// fresh reports whether entry can be used without revalidation.
+func (c *Cache) fresh(entry *cacheEntry) bool {
+	return entry != nil && time.Since(entry.Fetched) < c.ttl()
+}
+
+// store saves the records of a query. Failing to write the cache does not
+// fail the query, so errors are dropped.
+func (c *Cache) store(table string, params url.Values, records []json.RawMessage) {
+	entry := cacheEntry{Table: table, Query: params.Encode(), Fetched: time.Now(), Records: records}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return
+	}
+	path := c.path(table, params)
+	tmp, err := os.CreateTemp(c.Dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Stats summarizes the stored entries.
+func (c *Cache) Stats() (CacheStats, error) {
+	stats := CacheStats{Dir: c.Dir}
+	files, err := filepath.Glob(filepath.Join(c.Dir, "*.json"))
+	if err != nil {
+		return stats, err
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var entry cacheEntry
+		if json.Unmarshal(data, &entry) != nil {
+			continue
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+		if !c.fresh(&entry) {
+			stats.Expired++
+		}
+		if stats.Oldest.IsZero() || entry.Fetched.Before(stats.Oldest) {
+			stats.Oldest = entry.Fetched
+		}
+		if entry.Fetched.After(stats.Newest) {
+			stats.Newest = entry.Fetched
+		}
+	}
+	return stats, nil
+}
+
+// Clear removes every entry of the instance.
+func (c *Cache) Clear() error {
+	if err := os.RemoveAll(c.Dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %v", err)
+	}
+	return nil
+}
+
+// cacheParams returns params with sys_id and sys_updated_on added to
+// sysparm_fields, which revalidation needs.
+func cacheParams(params url.Values) url.Values {
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = v
+	}
+	if fields := q.Get("sysparm_fields"); fields != "" {
+		q.Set("sysparm_fields", fields+",sys_id,sys_updated_on")
+	}
+	return q
+}
+
+// cachedRecords returns the records of a Table API query, using c.Cache
+// when one is set.
+func (c *Client) cachedRecords(ctx context.Context, table string, params url.Values) ([]json.RawMessage, error) {
+	if c.Cache == nil {
+		return collect[json.RawMessage](c.NewPager(ctx, table, params))
+	}
+	params = cacheParams(params)
+	entry := c.Cache.load(table, params)
+	if c.Cache.fresh(entry) {
+		return entry.Records, nil
+	}
+	if entry != nil {
+		records, ok, err := c.revalidate(ctx, table, params, entry.Records)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			c.Cache.store(table, params, records)
+			return records, nil
+		}
+	}
+
+	records, err := collect[json.RawMessage](c.NewPager(ctx, table, params))
+	if err != nil {
+		return nil, err
+	}
+	c.Cache.store(table, params, records)
+	return records, nil
+}
+
+// collectCached is collect for a query that may be served from the cache.
+func collectCached[T any](ctx context.Context, c *Client, table string, params url.Values) ([]T, error) {
+	raws, err := c.cachedRecords(ctx, table, params)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRecords[T](raws)
+}
+
+// revalidate brings cached records up to date by fetching only the records
+// updated since the newest cached one. It reports false when that is not
+// possible: display values hide the raw sys_updated_on, ^NQ queries cannot
+// take an extra condition, a changed record count means records were
+// deleted, and added or changed records may move in an ordered query.
+func (c *Client) revalidate(ctx context.Context, table string, params url.Values, cached []json.RawMessage) ([]json.RawMessage, bool, error) {
+	query := params.Get("sysparm_query")
+	if dv := params.Get("sysparm_display_value"); (dv != "" && dv != "false") || strings.Contains(query, "^NQ") {
+		return nil, false, nil
+	}
+
+	type stamp struct {
+		SysID   string `json:"sys_id"`
+		Updated string `json:"sys_updated_on"`
+	}
+	stamps, err := decodeRecords[stamp](cached)
+	if err != nil || len(stamps) == 0 {
+		return nil, false, nil
+	}
+	newest := ""
+	for _, s := range stamps {
+		if s.SysID == "" || s.Updated == "" {
+			return nil, false, nil
+		}
+		newest = max(newest, s.Updated)
+	}
+
+	changedParams := url.Values{}
+	for k, v := range params {
+		changedParams[k] = v
+	}
+	// Records saved in the same second as the newest one may be missing
+	since := NewQuery().Where("sys_updated_on", GreaterOrEq, newest).String()
+	if query != "" {
+		since = query + "^" + since
+	}
+	changedParams.Set("sysparm_query", since)
+	changed, err := collect[json.RawMessage](c.NewPager(ctx, table, changedParams))
+	if err != nil {
+		return nil, false, err
+	}
+
+	records := append([]json.RawMessage(nil), cached...)
+	index := map[string]int{}
+	for i, s := range stamps {
+		index[s.SysID] = i
+	}
+	ordered := orderedQuery(query)
+	for _, raw := range changed {
+		var s stamp
+		if json.Unmarshal(raw, &s) != nil {
+			return nil, false, nil
+		}
+		i, ok := index[s.SysID]
+		switch {
+		case ok && sameJSON(records[i], raw):
+			// The newest cached record is always fetched again
+		case ordered:
+			return nil, false, nil
+		case ok:
+			records[i] = raw
+		default:
+			index[s.SysID] = len(records)
+			records = append(records, raw)
+		}
+	}
+
+	// A cheap count tells whether anything was deleted. A single page has
+	// it even when security rules hide the record on that page.
+	p := c.NewPager(ctx, table, params).Limit(1)
+	p.fetch()
+	if err := p.Err(); err != nil {
+		return nil, false, err
+	}
+	if p.Total() != len(records) {
+		return nil, false, nil
+	}
+	return records, true, nil
+}
+
+// orderedQuery reports whether an encoded query sorts its records.
+func orderedQuery(query string) bool {
+	for _, segment := range splitQuery(query) {
+		if strings.HasPrefix(segment, "ORDERBY") {
+			return true
+		}
+	}
+	return false
+}
+
+// sameJSON reports whether two JSON documents differ only in whitespace.
+func sameJSON(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return false
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
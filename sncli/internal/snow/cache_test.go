@@ -0,0 +1,155 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheRevalidatesIncrementally(t *testing.T) {
+	rows := []map[string]string{
+		{"sys_id": "1", "element": "active", "sys_updated_on": "2024-01-01 00:00:00"},
+		{"sys_id": "2", "element": "number", "sys_updated_on": "2024-01-02 00:00:00"},
+	}
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("sysparm_query")
+		queries = append(queries, query)
+		var result []map[string]string
+		for _, row := range rows {
+			if _, since, ok := strings.Cut(query, "^sys_updated_on>="); ok && row["sys_updated_on"] < since {
+				continue
+			}
+			result = append(result, row)
+		}
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(result)))
+		if limit, _ := strconv.Atoi(r.URL.Query().Get("sysparm_limit")); limit < len(result) {
+			result = result[:limit]
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	c.Cache = &Cache{Dir: t.TempDir(), TTL: time.Hour}
+	ctx := context.Background()
+	names := func() string {
+		fields, err := c.getTableFields(ctx, "incident")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range fields {
+			names = append(names, f.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	if got := names(); got != "active,number" || len(queries) != 1 {
+		t.Fatalf("first fetch: fields %s after %d requests", got, len(queries))
+	}
+	rows = append(rows, map[string]string{"sys_id": "3", "element": "state", "sys_updated_on": "2024-01-03 00:00:00"})
+	if got := names(); got != "active,number" || len(queries) != 1 {
+		t.Errorf("fresh entry: fields %s after %d requests, want the cached copy", got, len(queries))
+	}
+
+	// Expire the entry: only newer records and a count are fetched
+	c.Cache.TTL = time.Nanosecond
+	queries = nil
+	if got := names(); got != "active,number,state" {
+		t.Errorf("revalidated fields = %s", got)
+	}
+	if len(queries) != 2 || queries[0] != "name=incident^sys_updated_on>=2024-01-02 00:00:00" {
+		t.Errorf("revalidation queries = %q", queries)
+	}
+
+	// A deletion changes the count and forces a full fetch
+	rows = rows[1:]
+	queries = nil
+	if got := names(); got != "number,state" || len(queries) != 3 {
+		t.Errorf("after deletion: fields %s after queries %q", got, queries)
+	}
+
+	stats, err := c.Cache.Stats()
+	if err != nil || stats.Entries != 1 || stats.Expired != 1 {
+		t.Errorf("Stats() = %+v, %v", stats, err)
+	}
+	if err := c.Cache.Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(c.Cache.Dir); !os.IsNotExist(err) {
+		t.Errorf("cache dir still exists after Clear: %v", err)
+	}
+}
+
+func TestCacheRefetchesReorderedRecords(t *testing.T) {
+	rows := []map[string]string{
+		{"sys_id": "1", "name": "u_b", "sys_updated_on": "2024-01-01 00:00:00"},
+		{"sys_id": "2", "name": "u_d", "sys_updated_on": "2024-01-02 00:00:00"},
+	}
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("sysparm_query")
+		queries = append(queries, query)
+		var result []map[string]string
+		for _, row := range rows {
+			if _, since, ok := strings.Cut(query, "^sys_updated_on>="); ok && row["sys_updated_on"] < since {
+				continue
+			}
+			result = append(result, row)
+		}
+		sort.Slice(result, func(i, j int) bool { return result[i]["name"] < result[j]["name"] })
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(result)))
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	c.Cache = &Cache{Dir: t.TempDir(), TTL: time.Nanosecond}
+	params := url.Values{}
+	params.Set("sysparm_query", "nameSTARTSWITHu_^ORDERBYname")
+	names := func() string {
+		tables, err := collectCached[map[string]string](context.Background(), c, "sys_db_object", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, table := range tables {
+			names = append(names, table["name"])
+		}
+		return strings.Join(names, ",")
+	}
+
+	names()
+	// An unchanged entry still revalidates with two queries
+	queries = nil
+	if got := names(); got != "u_b,u_d" || len(queries) != 2 {
+		t.Errorf("unchanged: %s after queries %q", got, queries)
+	}
+
+	// A new record sorting before the cached ones forces a full fetch
+	rows = append(rows, map[string]string{"sys_id": "3", "name": "u_a", "sys_updated_on": "2024-01-03 00:00:00"})
+	queries = nil
+	if got := names(); got != "u_a,u_b,u_d" || len(queries) != 2 {
+		t.Errorf("after insert: %s after queries %q", got, queries)
+	}
+}
+
+func TestNewCacheDir(t *testing.T) {
+	t.Setenv("HOME", "/home/test")
+	c, err := NewCache("https://itsm.example.com:8443/snow", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/home/test/.sncli/cache/itsm.example.com_8443_snow/admin"; c.Dir != want {
+		t.Errorf("Dir = %s, want %s", c.Dir, want)
+	}
+}
@@ -0,0 +1,83 @@
+package snow
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// choiceLanguage is the language whose sys_choice rows are read. Every
+// translation has its own rows, and the base ones are in English.
+const choiceLanguage = "en"
+
+// Choice is one value of a choice field. Choices of a dependent field
+// apply when the field it depends on has DependentValue.
+type Choice struct {
+	Value          string `json:"value"`
+	Label          string `json:"label"`
+	Sequence       int    `json:"sequence"`
+	DependentValue string `json:"dependent_value,omitempty"`
+}
+
+// choiceEntry is a sys_choice row as the Table API returns it
+type choiceEntry struct {
+	Table    string `json:"name"`
+	Element  string `json:"element"`
+	Value    string `json:"value"`
+	Label    string `json:"label"`
+	Sequence string `json:"sequence"`
+
+	DependentValue string `json:"dependent_value"`
+}
+
+// choiceKey identifies the choice list of a field on one table.
+type choiceKey struct {
+	table, element string
+}
+
+// getChoices returns the active choices defined on tables, keyed by table
+// and field, in sequence order.
+func (c *Client) getChoices(ctx context.Context, tables []string) (map[choiceKey][]Choice, error) {
+	var params []url.Values
+	for lo := 0; lo < len(tables); lo += inChunk {
+		p := url.Values{}
+		p.Set("sysparm_query", NewQuery().
+			Where("name", In, tables[lo:min(lo+inChunk, len(tables))]...).
+			Eq("inactive", "false").
+			Eq("language", choiceLanguage).
+			OrderBy("name").
+			OrderBy("element").
+			OrderBy("sequence").
+			String())
+		p.Set("sysparm_fields", "name,element,value,label,sequence,dependent_value")
+		p.Set("sysparm_exclude_reference_link", "true")
+		params = append(params, p)
+	}
+	entries, err := collectEach[choiceEntry](ctx, c, "choices", "sys_choice", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch choices: %w", err)
+	}
+
+	choices := map[choiceKey][]Choice{}
+	for _, chunk := range entries {
+		for _, e := range chunk {
+			seq, _ := strconv.Atoi(e.Sequence)
+			key := choiceKey{e.Table, e.Element}
+			choices[key] = append(choices[key], Choice{Value: e.Value, Label: e.Label, Sequence: seq, DependentValue: e.DependentValue})
+		}
+	}
+	return choices, nil
+}
+
+// fieldChoices returns the choices of a field as seen from the first table
+// of chain. A table may replace the choice list it inherits, so the nearest
+// table with choices for the field wins.
+func fieldChoices(choices map[choiceKey][]Choice, chain []string, element string) []Choice {
+	for _, table := range chain {
+		if list, ok := choices[choiceKey{table, element}]; ok {
+			return list
+		}
+	}
+	return nil
+}
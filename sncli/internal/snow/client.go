@@ -1,266 +1,358 @@
-	package snow
-
-	import (
-	    "bytes"
-	    "encoding/json"
-	    "fmt"
-	    "io"
-	    "net/http"
-	    "path"
-	)
-
-	// UserInfo contains authenticated user details
-	type UserInfo struct {
-	    Name     string `json:"name"`
-	    Email    string `json:"email"`
-	    UserID   string `json:"user_id"`
+package snow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// UserInfo contains authenticated user details
+type UserInfo struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	UserID   string `json:"user_id"`
+	UserName string `json:"user_name"`
+}
+
+// Client represents a ServiceNow API client
+type Client struct {
+	BaseURL    string
+	Username   string
+	Password   string
+	httpClient *http.Client
+	UserInfo   *UserInfo
+
+	// PageSize is the number of records requested per page of a table
+	// query. Zero means DefaultPageSize.
+	PageSize int
+	// MaxRecords caps the number of records a single table query may
+	// return. Zero means DefaultMaxRecords.
+	MaxRecords int
+
+	// Concurrency bounds the per-table metadata requests run at once.
+	// Zero means DefaultConcurrency.
+	Concurrency int
+	// Progress, if set, is told how far per-table metadata fetches have
+	// got.
+	Progress ProgressFunc
+	// BatchSize is the number of per-table metadata requests bundled into
+	// one Batch API call. Zero means DefaultBatchSize; one or less turns
+	// batching off.
+	BatchSize int
+	noBatch   atomic.Bool
+	// Cache, if set, stores table and dictionary metadata on disk.
+	Cache *Cache
+
+	// Retry controls retries of failed requests.
+	Retry RetryPolicy
+	// Timeout bounds each HTTP attempt. Zero means no per-attempt timeout;
+	// the caller's context still applies.
+	Timeout time.Duration
+
+	// Auth selects basic or OAuth 2.0 authentication.
+	Auth AuthMethod
+	// ClientID and ClientSecret identify the OAuth application registered
+	// on the instance.
+	ClientID     string
+	ClientSecret string
+	// Token is the cached OAuth token. It is refreshed automatically.
+	Token *Token
+	// OnToken, if set, is called whenever a new OAuth token is issued so
+	// the caller can persist it.
+	OnToken func(*Token)
+
+	tokenMu sync.Mutex
+}
+
+// Error types for the client
+type AuthError struct {
+	Message string
+	Status  int
+	// token is the OAuth access token the failed request carried.
+	token string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication failed: %s (status: %d)", e.Message, e.Status)
+}
+
+// NewClient creates a new ServiceNow API client. The instance may be a bare
+// instance name, a host name or a full URL; see NormalizeInstanceURL.
+func NewClient(instance, username, password string) (*Client, error) {
+	if instance == "" || username == "" || password == "" {
+		return nil, fmt.Errorf("instance, username and password are required")
+	}
+	baseURL, err := NormalizeInstanceURL(instance)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		BaseURL:    baseURL,
+		Username:   username,
+		Password:   password,
+		Auth:       AuthBasic,
+		httpClient: &http.Client{},
+		Retry:      DefaultRetryPolicy,
+		Timeout:    DefaultTimeout,
+	}, nil
+}
+
+// NewOAuthClient creates a ServiceNow API client that authenticates with
+// OAuth 2.0. Set Username and Password for the password grant, or Token to
+// resume from a stored refresh token. The secret may be empty for public
+// clients that sign in with WebLogin.
+func NewOAuthClient(instance, clientID, clientSecret string) (*Client, error) {
+	if instance == "" || clientID == "" {
+		return nil, fmt.Errorf("instance and client ID are required")
+	}
+	baseURL, err := NormalizeInstanceURL(instance)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		BaseURL:      baseURL,
+		Auth:         AuthOAuth,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		httpClient:   &http.Client{},
+		Retry:        DefaultRetryPolicy,
+		Timeout:      DefaultTimeout,
+	}, nil
+}
+
+// Authenticate verifies credentials and returns user information. When the
+// username is not known, as after a web login, the instance is asked for the
+// user the token belongs to and Username is filled in.
+func (c *Client) Authenticate(ctx context.Context) (*UserInfo, error) {
+	username := c.Username
+	if username == "" {
+		username = "javascript:gs.getUserName()"
+	}
+	params := url.Values{}
+	params.Set("sysparm_query", NewQuery().Eq("user_name", username).String())
+	data, err := c.Request(ctx, "GET", "/api/now/v1/table/sys_user?"+params.Encode(), nil)
+	if err != nil {
+		// Rejections keep their status; transport failures and
+		// cancellation are not authentication errors.
+		return nil, err
 	}
 
-	// Client represents a ServiceNow API client
-	type Client struct {
-	    BaseURL     string
-	    Username    string
-	    Password    string
-	    httpClient  *http.Client
-	    UserInfo    *UserInfo
+	var response struct {
+		Result []UserInfo `json:"result"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse user info: %w", err)
 	}
 
-	// Error types for the client
-	type AuthError struct {
-	    Message string
-	    Status  int
+	if len(response.Result) == 0 {
+		return nil, &AuthError{Message: "user not found", Status: 404}
 	}
 
-	func (e *AuthError) Error() string {
-	    return fmt.Sprintf("authentication failed: %s (status: %d)", e.Message, e.Status)
+	c.UserInfo = &response.Result[0]
+	if c.Username == "" {
+		c.Username = c.UserInfo.UserName
+	}
+	return c.UserInfo, nil
+}
+
+// SaveConfig saves the client configuration under the given profile, or the
+// active profile when it is empty. OAuth clients store the client credentials
+// and token instead of the user's password.
+func (c *Client) SaveConfig(profile string) error {
+	cfg := &Config{
+		Profile:    profile,
+		Instance:   c.BaseURL,
+		Username:   c.Username,
+		AuthMethod: c.Auth,
 	}
+	if c.Auth == AuthOAuth {
+		cfg.ClientID = c.ClientID
+		cfg.ClientSecret = c.ClientSecret
+		cfg.Token = c.Token
+	} else {
+		cfg.Password = c.Password
+	}
+	return SaveConfig(cfg)
+}
+
+func (c *Client) pageSize() int {
+	if c.PageSize > 0 {
+		return c.PageSize
+	}
+	return DefaultPageSize
+}
 
-	// NewClient creates a new ServiceNow API client
-	func NewClient(instanceName, username, password string) (*Client, error) {
-	    if instanceName == "" || username == "" || password == "" {
-	        return nil, fmt.Errorf("instance name, username and password are required")
-	    }
-	    baseURL := fmt.Sprintf("https://%s.service-now.com", instanceName)
-	    return &Client{
-	        BaseURL:     baseURL,
-	        Username:    username,
-	        Password:    password,
-	        httpClient:  &http.Client{},
-	    }, nil
+func (c *Client) maxRecords() int {
+	if c.MaxRecords > 0 {
+		return c.MaxRecords
+	}
+	return DefaultMaxRecords
+}
+
+// Request makes an HTTP request to the ServiceNow API
+func (c *Client) Request(ctx context.Context, method, endpoint string, data interface{}) ([]byte, error) {
+	body, _, err := c.do(ctx, method, endpoint, data)
+	return body, err
+}
+
+// do performs the request, retrying according to c.Retry, and returns the
+// response body along with its headers, which the pager needs for Link and
+// X-Total-Count.
+func (c *Client) do(ctx context.Context, method, endpoint string, data interface{}) ([]byte, http.Header, error) {
+	var payload []byte
+	if data != nil {
+		jsonData, err := json.Marshal(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal request data: %w", err)
+		}
+		payload = jsonData
 	}
 
-	// Authenticate verifies credentials and returns user information
-	func (c *Client) Authenticate() (*UserInfo, error) {
-	    endpoint := "/api/now/v1/table/sys_user?sysparm_query=user_name=" + c.Username
-	    data, err := c.Request("GET", endpoint, nil)
-	    if err != nil {
-	        return nil, &AuthError{Message: err.Error(), Status: 401}
-	    }
-
-	    var response struct {
-	        Result []UserInfo `json:"result"`
-	    }
-	    if err := json.Unmarshal(data, &response); err != nil {
-	        return nil, fmt.Errorf("failed to parse user info: %w", err)
-	    }
-
-	    if len(response.Result) == 0 {
-	        return nil, &AuthError{Message: "user not found", Status: 404}
-	    }
-
-	    c.UserInfo = &response.Result[0]
-	    return c.UserInfo, nil
+	reauthorized := false
+	for attempt := 0; ; attempt++ {
+		body, header, err := c.attempt(ctx, method, endpoint, payload)
+		if err == nil {
+			return body, header, nil
+		}
+		var authErr *AuthError
+		if c.Auth == AuthOAuth && !reauthorized && errors.As(err, &authErr) && authErr.Status == http.StatusUnauthorized {
+			// The token was revoked or expired early; refresh it once
+			// without counting against the retry budget.
+			reauthorized = true
+			c.expireToken(authErr.token)
+			attempt--
+			continue
+		}
+		if attempt >= c.Retry.MaxRetries || !shouldRetry(ctx, method, err) {
+			return nil, header, err
+		}
+		if werr := c.Retry.wait(ctx, attempt+1, header); werr != nil {
+			return nil, header, werr
+		}
+	}
+}
+
+// attempt sends a single HTTP request bounded by c.Timeout.
+func (c *Client) attempt(ctx context.Context, method, endpoint string, payload []byte) ([]byte, http.Header, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
 	}
 
-	// SaveConfig saves the client configuration
-	func (c *Client) SaveConfig() error {
-	    cfg := &Config{
-	        Instance: path.Base(c.BaseURL),
-	        Username: c.Username,
-	        Password: c.Password,
-	    }
-	    return SaveConfig(cfg)
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
 	}
 
-	// Request makes an HTTP request to the ServiceNow API
-	func (c *Client) Request(method, endpoint string, data interface{}) ([]byte, error) {
-	    var body io.Reader
-	    if data != nil {
-	        jsonData, err := json.Marshal(data)
-	        if err != nil {
-	            return nil, fmt.Errorf("failed to marshal request data: %w", err)
-	        }
-	        body = bytes.NewBuffer(jsonData)
-	    }
-
-	    req, err := http.NewRequest(method, c.BaseURL+endpoint, body)
-	    if err != nil {
-	        return nil, fmt.Errorf("failed to create request: %w", err)
-	    }
-
-	    req.SetBasicAuth(c.Username, c.Password)
-	    req.Header.Set("Content-Type", "application/json")
-	    req.Header.Set("Accept", "application/json")
-
-	    resp, err := c.httpClient.Do(req)
-	    if err != nil {
-	        return nil, fmt.Errorf("failed to send request: %w", err)
-	    }
-	    defer resp.Body.Close()
-
-	    responseBody, err := io.ReadAll(resp.Body)
-	    if err != nil {
-	        return nil, fmt.Errorf("failed to read response: %w", err)
-	    }
-
-	    if resp.StatusCode < 200 || resp.StatusCode > 299 {
-	        return nil, &AuthError{
-	            Message: string(responseBody),
-	            Status:  resp.StatusCode,
-	        }
-	    }
-
-	    return responseBody, nil
-	      }
-
-							// Table represents a ServiceNow table metadata
-							type Table struct {
-							    Name            string           `json:"name"`
-							    Label           string           `json:"label"`
-							    SysID          string           `json:"sys_id"`
-							    Scope          string           `json:"scope"`
-							    Description    string           `json:"description"`
-							    SuperClass     string           `json:"super_class"`
-							    AccessibleFrom string           `json:"accessible_from"`
-							    Extendable     bool             `json:"extendable"`
-							    NumberPrefix   string           `json:"number_prefix"`
-							    Fields        []TableField      `json:"fields,omitempty"`
-							}
-
-							// TableField represents a field in a table
-							type TableField struct {
-							    Name          string `json:"name"`
-							    Label         string `json:"label"`
-							    Type         string `json:"type"`
-							    Length       int    `json:"length"`
-							    Reference    string `json:"reference"`
-							    IsMandatory  bool   `json:"mandatory"`
-							    IsUnique     bool   `json:"unique"`
-							}
-
-							// RelationshipInfo represents a relationship between tables
-							type RelationshipInfo struct {
-							    SourceTable     string `json:"source_table"`
-							    TargetTable     string `json:"target_table"`
-							    Field          string `json:"field"`
-							    Type           string `json:"type"`
-							    IsParentChild  bool   `json:"is_parent_child"`
-							    Cardinality    string `json:"cardinality"`
-							}
-
-							// GetTables retrieves all tables from a specific scope
-							func (c *Client) GetTables(scope string, detailed bool) ([]Table, error) {
-							    query := "sys_scope.scope=" + scope
-							    if scope == "global" {
-							        query = "sys_scope=global"
-							    }
-							    
-							    endpoint := "/api/now/table/sys_db_object?sysparm_query=" + query
-							    if detailed {
-							        endpoint += "&sysparm_display_value=true&sysparm_fields=name,label,sys_id,scope,description,super_class,accessible_from,extendable,number_prefix"
-							    }
-
-							    data, err := c.Request("GET", endpoint, nil)
-							    if err != nil {
-							        return nil, fmt.Errorf("failed to fetch tables: %w", err)
-							    }
-
-							    var response struct {
-							        Result []Table `json:"result"`
-							    }
-							    if err := json.Unmarshal(data, &response); err != nil {
-							        return nil, fmt.Errorf("failed to parse table data: %w", err)
-							    }
-
-							    if detailed {
-							        for i, table := range response.Result {
-							            fields, err := c.getTableFields(table.Name)
-							            if err != nil {
-							                return nil, fmt.Errorf("failed to fetch fields for table %s: %w", table.Name, err)
-							            }
-							            response.Result[i].Fields = fields
-							        }
-							    }
-
-							    return response.Result, nil
-							}
-
-							// getTableFields retrieves all fields for a specific table
-							func (c *Client) getTableFields(tableName string) ([]TableField, error) {
-							    endpoint := fmt.Sprintf("/api/now/table/sys_dictionary?sysparm_query=name=%s&sysparm_fields=element,column_label,internal_type,max_length,reference,mandatory,unique", tableName)
-							    
-							    data, err := c.Request("GET", endpoint, nil)
-							    if err != nil {
-							        return nil, fmt.Errorf("failed to fetch fields: %w", err)
-							    }
-
-							    var response struct {
-							        Result []TableField `json:"result"`
-							    }
-							    if err := json.Unmarshal(data, &response); err != nil {
-							        return nil, fmt.Errorf("failed to parse field data: %w", err)
-							    }
-
-							    return response.Result, nil
-							}
-
-							// GetRelationships retrieves all relationships for the given tables
-							func (c *Client) GetRelationships(tables []Table) ([]RelationshipInfo, error) {
-							    var relationships []RelationshipInfo
-
-							    for _, table := range tables {
-							        // Get reference fields pointing to this table
-							        endpoint := fmt.Sprintf("/api/now/table/sys_dictionary?sysparm_query=internal_type=reference^reference=%s&sysparm_fields=name,element,column_label,reference,table", table.Name)
-							        data, err := c.Request("GET", endpoint, nil)
-							        if err != nil {
-							            return nil, fmt.Errorf("failed to fetch relationships for table %s: %w", table.Name, err)
-							        }
-
-							        var response struct {
-							            Result []struct {
-							                Name      string `json:"table"`
-							                Field     string `json:"element"`
-							                Label     string `json:"column_label"`
-							                Reference string `json:"reference"`
-							            } `json:"result"`
-							        }
-							        if err := json.Unmarshal(data, &response); err != nil {
-							            return nil, fmt.Errorf("failed to parse relationship data: %w", err)
-							        }
-
-							        for _, rel := range response.Result {
-							            relationships = append(relationships, RelationshipInfo{
-							                SourceTable:    rel.Name,
-							                TargetTable:    table.Name,
-							                Field:         rel.Field,
-							                Type:          "reference",
-							                IsParentChild: false,
-							                Cardinality:   "N:1",
-							            })
-							        }
-
-							        // Check for parent-child relationships
-							        if table.SuperClass != "" {
-							            relationships = append(relationships, RelationshipInfo{
-							                SourceTable:    table.Name,
-							                TargetTable:    table.SuperClass,
-							                Field:         "super_class",
-							                Type:          "inheritance",
-							                IsParentChild: true,
-							                Cardinality:   "1:1",
-							            })
-							        }
-							    }
-
-							    return relationships, nil
-							}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+endpoint, body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := c.authorize(ctx, req); err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.Header, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, resp.Header, &AuthError{
+			Message: string(responseBody),
+			Status:  resp.StatusCode,
+			token:   strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer "),
+		}
+	}
+
+	return responseBody, resp.Header, nil
+}
+
+// Table represents a ServiceNow table metadata
+type Table struct {
+	Name           string `json:"name"`
+	Label          string `json:"label"`
+	SysID          string `json:"sys_id"`
+	Scope          string `json:"scope"`
+	Description    string `json:"description"`
+	SuperClass     string `json:"super_class"`
+	AccessibleFrom string `json:"accessible_from"`
+	Extendable     bool   `json:"extendable"`
+	NumberPrefix   string `json:"number_prefix"`
+	// Hierarchy is the table followed by the tables it extends, nearest
+	// first, whatever their scope.
+	Hierarchy []string     `json:"hierarchy,omitempty"`
+	Fields    []TableField `json:"fields,omitempty"`
+	// Profile describes the records of the table, when profiled.
+	Profile *DataProfile `json:"profile,omitempty"`
+}
+
+// TableField represents a field in a table
+type TableField struct {
+	Name        string `json:"name"`
+	Label       string `json:"label"`
+	Type        string `json:"type"`
+	Length      int    `json:"length"`
+	Reference   string `json:"reference"`
+	IsMandatory bool   `json:"mandatory"`
+	IsUnique    bool   `json:"unique"`
+	// Display marks the field shown for records of the table, for example
+	// in reference fields pointing at it.
+	Display      bool   `json:"display"`
+	DefaultValue string `json:"default_value,omitempty"`
+	// DependentOn is the field whose value the field depends on, such as
+	// the table field of a document ID or the parent of a dependent choice.
+	DependentOn        string            `json:"dependent_on,omitempty"`
+	Attributes         map[string]string `json:"attributes,omitempty"`
+	ReferenceQualifier string            `json:"reference_qualifier,omitempty"`
+	// DefinedOn is the table whose dictionary defines the field; the field
+	// is Inherited when that is an ancestor of the table it is listed on.
+	DefinedOn string   `json:"defined_on,omitempty"`
+	Inherited bool     `json:"inherited"`
+	Choices   []Choice `json:"choices,omitempty"`
+}
+
+// GetTables retrieves all tables from a specific scope
+func (c *Client) GetTables(ctx context.Context, scope string, detailed bool) ([]Table, error) {
+	return c.SelectTables(ctx, TableSelection{Scopes: []string{scope}}, detailed)
+}
+
+// GetTable retrieves one table by name, in any scope.
+func (c *Client) GetTable(ctx context.Context, name string, detailed bool) (Table, error) {
+	tables, err := c.SelectTables(ctx, TableSelection{Tables: []string{name}}, detailed)
+	if err != nil {
+		return Table{}, err
+	}
+	if len(tables) == 0 {
+		return Table{}, fmt.Errorf("table %s not found", name)
+	}
+	return tables[0], nil
+}
+
+// getTableFields retrieves all fields for a specific table
+func (c *Client) getTableFields(ctx context.Context, tableName string) ([]TableField, error) {
+	entries, err := collectCached[dictionaryEntry](ctx, c, "sys_dictionary", fieldParams(tableName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fields: %w", err)
+	}
+	return tableFields(entries), nil
+}
@@ -1,69 +1,323 @@
-	package snow
+package snow
 
-	import (
-	  "encoding/json"
-	  "fmt"
-	  "os"
-	  "path/filepath"
-	)
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
 
-	type Config struct {
-	  Instance string `json:"instance"`
-	  Username string `json:"username"`
-	  Password string `json:"password"`
+type Config struct {
+	// Profile is the name the config is stored under. It is not written to
+	// the file; the profiles map key is authoritative.
+	Profile string `json:"-"`
+
+	// Instance is the instance base URL. Older configs may hold a bare
+	// instance name or host, which NewClient normalizes.
+	Instance string `json:"instance"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+
+	// AuthMethod is basic when empty, for configs written before OAuth
+	// support.
+	AuthMethod   AuthMethod `json:"auth_method,omitempty"`
+	ClientID     string     `json:"client_id,omitempty"`
+	ClientSecret string     `json:"client_secret,omitempty"`
+	Token        *Token     `json:"token,omitempty"`
+
+	// Sealed holds Password, ClientSecret and Token encrypted when the
+	// config file has encryption enabled. See ConfigFile.Open.
+	Sealed string `json:"secrets,omitempty"`
+
+	// ephemeral marks a config built from the environment, which is never
+	// written back to the config file.
+	ephemeral bool
+}
+
+// CacheUser names the user whose metadata the cache keeps for c: the
+// username, or the OAuth client when there is none.
+func (c *Config) CacheUser() string {
+	if c.Username != "" {
+		return c.Username
 	}
+	return c.ClientID
+}
 
-	func getConfigPath() (string, error) {
-	  homeDir, err := os.UserHomeDir()
-	  if err != nil {
-	    return "", fmt.Errorf("failed to get home directory: %v", err)
-	  }
-	  return filepath.Join(homeDir, ".sncli", "config.json"), nil
+// NewClientFromConfig creates a client for the stored configuration. OAuth
+// tokens issued while the client is in use are written back to the config
+// file so the next command can reuse them.
+func NewClientFromConfig(cfg *Config) (*Client, error) {
+	if cfg.AuthMethod != AuthOAuth {
+		return NewClient(cfg.Instance, cfg.Username, cfg.Password)
 	}
 
-	func ReadConfig() (*Config, error) {
-	  configPath, err := getConfigPath()
-	  if err != nil {
-	    return nil, err
-	  }
+	client, err := NewOAuthClient(cfg.Instance, cfg.ClientID, cfg.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	client.Username = cfg.Username
+	client.Token = cfg.Token
+	var saveFailed sync.Once
+	client.OnToken = func(t *Token) {
+		cfg.Token = t
+		if cfg.ephemeral {
+			return
+		}
+		// Failing to cache the token only costs a refresh next time, which
+		// is worth explaining once.
+		if err := SaveConfig(cfg); err != nil {
+			saveFailed.Do(func() {
+				WarnFunc(fmt.Sprintf("could not save the new OAuth token, so the next command refreshes it again: %v", err))
+			})
+		}
+	}
+	return client, nil
+}
 
-	  data, err := os.ReadFile(configPath)
-	  if err != nil {
-	    if os.IsNotExist(err) {
-	      return nil, fmt.Errorf("no config file found at %s - please run 'connect' command first", configPath)
-	    }
-	    return nil, fmt.Errorf("failed to read config file: %v", err)
-	  }
+// DefaultProfile is the profile used when none is selected, and the one a
+// single-instance config file is migrated into.
+const DefaultProfile = "default"
 
-	  var config Config
-	  if err := json.Unmarshal(data, &config); err != nil {
-	    return nil, fmt.Errorf("failed to parse config file: %v", err)
-	  }
+// ProfileEnv selects the active profile when --profile is not given.
+const ProfileEnv = "SNCLI_PROFILE"
 
-	  return &config, nil
+// Environment variables that supply a config without a config file. When
+// InstanceEnv is set the config is built from the environment alone;
+// otherwise the credential variables override the selected profile.
+const (
+	InstanceEnv     = "SNCLI_INSTANCE"
+	UsernameEnv     = "SNCLI_USERNAME"
+	PasswordEnv     = "SNCLI_PASSWORD"
+	AuthMethodEnv   = "SNCLI_AUTH_METHOD"
+	ClientIDEnv     = "SNCLI_CLIENT_ID"
+	ClientSecretEnv = "SNCLI_CLIENT_SECRET"
+)
+
+// applyEnv overlays the credential environment variables on config. A
+// config with overrides becomes ephemeral so the overridden values are never
+// written into the profile.
+func (c *Config) applyEnv() error {
+	for env, field := range map[string]*string{
+		UsernameEnv:     &c.Username,
+		PasswordEnv:     &c.Password,
+		ClientIDEnv:     &c.ClientID,
+		ClientSecretEnv: &c.ClientSecret,
+	} {
+		if v := os.Getenv(env); v != "" {
+			*field = v
+			c.ephemeral = true
+		}
+	}
+	if v := os.Getenv(AuthMethodEnv); v != "" {
+		method, err := ParseAuthMethod(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", AuthMethodEnv, err)
+		}
+		c.AuthMethod = method
+		c.ephemeral = true
 	}
+	return nil
+}
+
+// ConfigFile is the on-disk layout of ~/.sncli/config.json: a set of named
+// profiles and the one in use.
+type ConfigFile struct {
+	Current    string             `json:"current_profile"`
+	Encryption *Encryption        `json:"encryption,omitempty"`
+	Profiles   map[string]*Config `json:"profiles"`
+}
 
-	func SaveConfig(config *Config) error {
-	  configPath, err := getConfigPath()
-	  if err != nil {
-	    return err
-	  }
+func getConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	return filepath.Join(homeDir, ".sncli", "config.json"), nil
+}
 
-	  // Create config directory if it doesn't exist
-	  configDir := filepath.Dir(configPath)
-	  if err := os.MkdirAll(configDir, 0700); err != nil {
-	    return fmt.Errorf("failed to create config directory: %v", err)
-	  }
+// legacyWarning shows the warning about an old config file that cannot be
+// migrated once per process.
+var legacyWarning sync.Once
 
-	  data, err := json.MarshalIndent(config, "", "  ")
-	  if err != nil {
-	    return fmt.Errorf("failed to marshal config: %v", err) 
-	  }
+// LoadConfigFile reads the config file. A file written before profiles
+// existed is migrated into the default profile and saved in the new layout,
+// with its secrets encrypted. When no passphrase is available to encrypt
+// them the old file is left as it is and a warning is shown. A missing file
+// yields an empty ConfigFile.
+func LoadConfigFile() (*ConfigFile, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return nil, err
+	}
 
-	  if err := os.WriteFile(configPath, data, 0600); err != nil {
-	    return fmt.Errorf("failed to write config file: %v", err)
-	  }
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &ConfigFile{Profiles: map[string]*Config{}}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
 
-	  return nil
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
 	}
 
+	if _, ok := probe["profiles"]; !ok {
+		var legacy Config
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %v", err)
+		}
+		file := &ConfigFile{
+			Current:  DefaultProfile,
+			Profiles: map[string]*Config{DefaultProfile: &legacy},
+		}
+		if err := file.Save(); err != nil {
+			if errors.Is(err, ErrNoPassphrase) {
+				// Rewriting the file would only move the plaintext secrets
+				legacyWarning.Do(func() {
+					WarnFunc("the config file has the old layout with plaintext secrets - set " + PassphraseEnv +
+						" or run 'sncli config rekey' to encrypt and migrate it")
+				})
+				return file, nil
+			}
+			return nil, fmt.Errorf("failed to migrate config file: %w", err)
+		}
+		return file, nil
+	}
+
+	var file ConfigFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	if file.Profiles == nil {
+		file.Profiles = map[string]*Config{}
+	}
+	return &file, nil
+}
+
+// Save writes the config file with owner-only permissions. Every profile's
+// secrets are sealed first; storing secrets in a plaintext file enables
+// encryption for the whole file.
+func (f *ConfigFile) Save() error {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return err
+	}
+
+	for _, config := range f.Profiles {
+		if config.hasSecrets() {
+			if err := f.EnableEncryption(); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	// Create config directory if it doesn't exist
+	configDir := filepath.Dir(configPath)
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+
+	profiles, err := f.sealed()
+	if err != nil {
+		return err
+	}
+	out := *f
+	out.Profiles = profiles
+
+	data, err := json.MarshalIndent(&out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %v", err)
+	}
+
+	if err := os.WriteFile(configPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+
+	return nil
+}
+
+// Resolve picks the profile to use: the explicit name if given, then
+// $SNCLI_PROFILE, then the file's current profile, then DefaultProfile.
+func (f *ConfigFile) Resolve(profile string) string {
+	if profile != "" {
+		return profile
+	}
+	if env := os.Getenv(ProfileEnv); env != "" {
+		return env
+	}
+	if f.Current != "" {
+		return f.Current
+	}
+	return DefaultProfile
+}
+
+// Names returns the profile names in sorted order.
+func (f *ConfigFile) Names() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ReadConfig returns the named profile, resolving an empty name as described
+// on ConfigFile.Resolve. $SNCLI_INSTANCE bypasses the config file entirely
+// and the other SNCLI_* credential variables override the profile's values.
+func ReadConfig(profile string) (*Config, error) {
+	if instance := os.Getenv(InstanceEnv); instance != "" {
+		config := &Config{Profile: profile, Instance: instance, ephemeral: true}
+		if err := config.applyEnv(); err != nil {
+			return nil, err
+		}
+		return config, nil
+	}
+
+	file, err := LoadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	name := file.Resolve(profile)
+	config, ok := file.Profiles[name]
+	if !ok {
+		if len(file.Profiles) == 0 {
+			configPath, _ := getConfigPath()
+			return nil, fmt.Errorf("no config file found at %s - please run 'connect' command first", configPath)
+		}
+		return nil, fmt.Errorf("profile %q not found - run 'connect --profile %s' or 'profile list'", name, name)
+	}
+	config.Profile = name
+	if err := file.Open(config); err != nil {
+		return nil, err
+	}
+	if err := config.applyEnv(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// SaveConfig stores config under its Profile, or the resolved active profile
+// when it has none. The first profile saved becomes the current one. Saving
+// secrets into a plaintext file enables encryption for the whole file.
+func SaveConfig(config *Config) error {
+	file, err := LoadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if config.Profile == "" {
+		config.Profile = file.Resolve("")
+	}
+	file.Profiles[config.Profile] = config
+	if file.Current == "" {
+		file.Current = config.Profile
+	}
+	return file.Save()
+}
@@ -0,0 +1,123 @@
+package snow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeLegacyConfig writes a config file from before profiles existed and
+// returns its path.
+func writeLegacyConfig(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ProfileEnv, "")
+	unlockedKeys = map[string][]byte{}
+
+	dir := filepath.Join(home, ".sncli")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	legacy := `{"instance":"dev123","username":"admin","password":"hunter2"}`
+	if err := os.WriteFile(path, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLegacyConfigMigratesToDefaultProfile(t *testing.T) {
+	path := writeLegacyConfig(t)
+	t.Setenv(PassphraseEnv, "correct horse")
+
+	cfg, err := ReadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != DefaultProfile || cfg.Instance != "dev123" || cfg.Password != "hunter2" {
+		t.Errorf("migrated config = %+v", cfg)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Fatalf("migrated password stored in plaintext:\n%s", data)
+	}
+
+	// A second profile is stored alongside without replacing the first.
+	if err := SaveConfig(&Config{Profile: "prod", Instance: "https://prod.example.com", Username: "ops"}); err != nil {
+		t.Fatal(err)
+	}
+	file, err := LoadConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Current != DefaultProfile || len(file.Profiles) != 2 {
+		t.Errorf("config file = current %q, %d profiles", file.Current, len(file.Profiles))
+	}
+
+	t.Setenv(ProfileEnv, "prod")
+	cfg, err = ReadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Username != "ops" {
+		t.Errorf("%s=prod resolved to %+v", ProfileEnv, cfg)
+	}
+}
+
+func TestLegacyConfigKeptWithoutPassphrase(t *testing.T) {
+	path := writeLegacyConfig(t)
+	t.Setenv(PassphraseEnv, "")
+	var warnings []string
+	defer func(warn func(string)) { WarnFunc = warn }(WarnFunc)
+	WarnFunc = func(msg string) { warnings = append(warnings, msg) }
+	legacyWarning = sync.Once{}
+
+	if _, err := LoadConfigFile(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ReadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Password != "hunter2" || len(warnings) != 1 {
+		t.Errorf("config = %+v, warnings = %q", cfg, warnings)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "profiles") {
+		t.Errorf("legacy config rewritten without encryption:\n%s", data)
+	}
+}
+
+func TestTokenSaveFailureWarnsOnce(t *testing.T) {
+	writeLegacyConfig(t)
+	t.Setenv(PassphraseEnv, "")
+	var warnings []string
+	defer func(warn func(string)) { WarnFunc = warn }(WarnFunc)
+	WarnFunc = func(msg string) { warnings = append(warnings, msg) }
+
+	cfg := &Config{Profile: "oauth", Instance: "https://dev.example.com", AuthMethod: AuthOAuth, ClientID: "id", ClientSecret: "s"}
+	client, err := NewClientFromConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.OnToken(&Token{AccessToken: "a"})
+	client.OnToken(&Token{AccessToken: "b"})
+	var tokenWarnings int
+	for _, w := range warnings {
+		if strings.Contains(w, "OAuth token") {
+			tokenWarnings++
+		}
+	}
+	if tokenWarnings != 1 || cfg.Token.AccessToken != "b" {
+		t.Errorf("warnings = %q, token = %+v", warnings, cfg.Token)
+	}
+}
@@ -0,0 +1,128 @@
+package snow
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fieldParams selects the sys_dictionary entries of a table.
+func fieldParams(tableName string) url.Values {
+	params := url.Values{}
+	params.Set("sysparm_query", NewQuery().Eq("name", tableName).String())
+	params.Set("sysparm_fields", dictionaryColumns)
+	params.Set("sysparm_exclude_reference_link", "true")
+	return params
+}
+
+// tableFields converts sys_dictionary entries into fields.
+func tableFields(entries []dictionaryEntry) []TableField {
+	fields := make([]TableField, 0, len(entries))
+	for _, e := range entries {
+		// The entry without an element describes the table itself
+		if e.Element == "" {
+			continue
+		}
+		length, _ := strconv.Atoi(e.MaxLength)
+		fields = append(fields, TableField{
+			Name:               e.Element,
+			Label:              e.Label,
+			Type:               e.Type,
+			Length:             length,
+			Reference:          e.Reference,
+			IsMandatory:        e.Mandatory == "true",
+			IsUnique:           e.Unique == "true",
+			Display:            e.Display == "true",
+			DefaultValue:       e.DefaultValue,
+			DependentOn:        e.DependentOn,
+			Attributes:         parseAttributes(e.Attributes),
+			ReferenceQualifier: e.qualifier(),
+		})
+	}
+	return fields
+}
+
+// dictionaryColumns are the sys_dictionary columns read for each field.
+const dictionaryColumns = "element,column_label,internal_type,max_length,reference,mandatory,unique," +
+	"display,default_value,dependent_on_field,attributes," +
+	"use_reference_qualifier,reference_qual,reference_qual_condition,dynamic_ref_qual.name"
+
+// dictionaryEntry is a sys_dictionary row as the Table API returns it, with
+// every value as a string
+type dictionaryEntry struct {
+	Element   string `json:"element"`
+	Label     string `json:"column_label"`
+	Type      string `json:"internal_type"`
+	MaxLength string `json:"max_length"`
+	Reference string `json:"reference"`
+	Mandatory string `json:"mandatory"`
+	Unique    string `json:"unique"`
+
+	Display      string `json:"display"`
+	DefaultValue string `json:"default_value"`
+	DependentOn  string `json:"dependent_on_field"`
+	Attributes   string `json:"attributes"`
+
+	UseQualifier       string `json:"use_reference_qualifier"`
+	Qualifier          string `json:"reference_qual"`
+	QualifierCondition string `json:"reference_qual_condition"`
+	DynamicQualifier   string `json:"dynamic_ref_qual.name"`
+}
+
+// qualifier returns the reference qualifier the entry uses: the encoded
+// query of a simple one, the script of an advanced one, or the name of a
+// dynamic filter option.
+func (e dictionaryEntry) qualifier() string {
+	switch e.UseQualifier {
+	case "simple":
+		return e.QualifierCondition
+	case "dynamic":
+		if e.DynamicQualifier == "" {
+			return ""
+		}
+		return "dynamic: " + e.DynamicQualifier
+	case "advanced":
+		return e.Qualifier
+	}
+	// Older entries have no qualifier type
+	if e.Qualifier != "" {
+		return e.Qualifier
+	}
+	return e.QualifierCondition
+}
+
+// parseAttributes parses dictionary attributes such as
+// "no_sort=true,ref_ac_columns=name;email". An attribute without a value
+// is set to "true", as the platform reads it.
+func parseAttributes(s string) map[string]string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	attrs := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if key == "" {
+			continue
+		}
+		if !ok {
+			value = "true"
+		}
+		attrs[key] = value
+	}
+	return attrs
+}
+
+// AttributeString renders the field's attributes sorted by name, in the
+// form the dictionary stores them.
+func (f TableField) AttributeString() string {
+	keys := make([]string, 0, len(f.Attributes))
+	for k := range f.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		keys[i] = k + "=" + f.Attributes[k]
+	}
+	return strings.Join(keys, ",")
+}
@@ -0,0 +1,52 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetTableFieldsMapsDictionaryEntries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fields := r.URL.Query().Get("sysparm_fields"); !strings.Contains(fields, "column_label,internal_type,max_length") {
+			t.Errorf("sysparm_fields = %s", fields)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": []map[string]string{
+			{"element": "", "column_label": "Order"},
+			{"element": "u_number", "column_label": "Number", "internal_type": "string", "max_length": "40",
+				"mandatory": "true", "unique": "true", "display": "true", "default_value": "javascript:getNextObjNumberPadded();",
+				"attributes": "no_sort, ignore_filter_on_new=true"},
+			{"element": "u_caller", "column_label": "Caller", "internal_type": "reference", "max_length": "32",
+				"reference": "sys_user", "use_reference_qualifier": "simple", "reference_qual_condition": "active=true",
+				"reference_qual": "javascript:ignored()"},
+			{"element": "u_group", "internal_type": "reference", "reference": "sys_user_group",
+				"use_reference_qualifier": "dynamic", "dynamic_ref_qual.name": "My Groups"},
+			{"element": "u_subcategory", "internal_type": "string", "dependent_on_field": "u_category"},
+		}})
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	fields, err := c.getTableFields(context.Background(), "u_order")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TableField{
+		{Name: "u_number", Label: "Number", Type: "string", Length: 40, IsMandatory: true, IsUnique: true, Display: true,
+			DefaultValue: "javascript:getNextObjNumberPadded();",
+			Attributes:   map[string]string{"no_sort": "true", "ignore_filter_on_new": "true"}},
+		{Name: "u_caller", Label: "Caller", Type: "reference", Length: 32, Reference: "sys_user", ReferenceQualifier: "active=true"},
+		{Name: "u_group", Type: "reference", Reference: "sys_user_group", ReferenceQualifier: "dynamic: My Groups"},
+		{Name: "u_subcategory", Type: "string", DependentOn: "u_category"},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields =\n%+v\nwant\n%+v", fields, want)
+	}
+	if got := fields[0].AttributeString(); got != "ignore_filter_on_new=true,no_sort=true" {
+		t.Errorf("attributes = %s", got)
+	}
+}
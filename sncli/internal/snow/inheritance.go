@@ -0,0 +1,164 @@
+package snow
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// tableColumns are the sys_db_object columns a Table is built from. Dot
+// walks return the parent table's name and the number prefix rather than
+// sys_ids.
+const tableColumns = "name,label,sys_id,sys_scope.scope,description,super_class.name,accessible_from,is_extendable,number_ref.prefix"
+
+// dbObject is a sys_db_object row as the Table API returns it, with every
+// value as a string
+type dbObject struct {
+	Name           string `json:"name"`
+	Label          string `json:"label"`
+	SysID          string `json:"sys_id"`
+	Scope          string `json:"sys_scope.scope"`
+	Description    string `json:"description"`
+	SuperClass     string `json:"super_class.name"`
+	AccessibleFrom string `json:"accessible_from"`
+	Extendable     string `json:"is_extendable"`
+	NumberPrefix   string `json:"number_ref.prefix"`
+}
+
+func (o dbObject) table() Table {
+	return Table{
+		Name:           o.Name,
+		Label:          o.Label,
+		SysID:          o.SysID,
+		Scope:          o.Scope,
+		Description:    o.Description,
+		SuperClass:     o.SuperClass,
+		AccessibleFrom: o.AccessibleFrom,
+		Extendable:     o.Extendable == "true",
+		NumberPrefix:   o.NumberPrefix,
+	}
+}
+
+// HierarchyPath renders the hierarchy of t, for example
+// "u_x -> task -> (base)".
+func (t Table) HierarchyPath() string {
+	chain := t.Hierarchy
+	if len(chain) == 0 {
+		chain = []string{t.Name}
+	}
+	return strings.Join(append(slices.Clone(chain), "(base)"), " -> ")
+}
+
+// resolveInheritance sets the hierarchy of every table, looking up
+// ancestors in any scope. When detailed is set the fields of each table
+// become its effective field set: its own fields followed by those it
+// inherits, nearest ancestor first, each marked with the table defining it.
+func (c *Client) resolveInheritance(ctx context.Context, tables []Table, detailed bool) error {
+	parents := map[string]string{}
+	for _, t := range tables {
+		parents[t.Name] = t.SuperClass
+	}
+
+	// Look up ancestors outside the set one generation at a time
+	var ancestors []string
+	for {
+		var missing []string
+		for _, parent := range parents {
+			if _, ok := parents[parent]; parent != "" && !ok && !slices.Contains(missing, parent) {
+				missing = append(missing, parent)
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+		slices.Sort(missing)
+		params := make([]url.Values, 0, len(missing))
+		for lo := 0; lo < len(missing); lo += inChunk {
+			p := url.Values{}
+			p.Set("sysparm_query", NewQuery().Where("name", In, missing[lo:min(lo+inChunk, len(missing))]...).String())
+			p.Set("sysparm_fields", tableColumns)
+			p.Set("sysparm_exclude_reference_link", "true")
+			params = append(params, p)
+		}
+		found, err := collectEach[dbObject](ctx, c, "ancestors", "sys_db_object", params)
+		if err != nil {
+			return fmt.Errorf("failed to fetch parent tables: %w", err)
+		}
+		for _, name := range missing {
+			// A parent the user cannot read ends the chain
+			parents[name] = ""
+		}
+		for _, objects := range found {
+			for _, o := range objects {
+				parents[o.Name] = o.SuperClass
+			}
+		}
+		ancestors = append(ancestors, missing...)
+	}
+
+	for i := range tables {
+		tables[i].Hierarchy = hierarchy(tables[i].Name, parents)
+	}
+	if !detailed {
+		return nil
+	}
+
+	names := make([]string, 0, len(tables)+len(ancestors))
+	for _, t := range tables {
+		names = append(names, t.Name)
+	}
+	names = append(names, ancestors...)
+	params := make([]url.Values, len(names))
+	for i, name := range names {
+		params[i] = fieldParams(name)
+	}
+	entries, err := collectEach[dictionaryEntry](ctx, c, "fields", "sys_dictionary", params)
+	if err != nil {
+		return fmt.Errorf("failed to fetch table fields: %w", err)
+	}
+	own := map[string][]TableField{}
+	for i, name := range names {
+		own[name] = tableFields(entries[i])
+	}
+	choices, err := c.getChoices(ctx, names)
+	if err != nil {
+		return err
+	}
+	for i := range tables {
+		tables[i].Fields = effectiveFields(tables[i].Hierarchy, own)
+		for j, f := range tables[i].Fields {
+			tables[i].Fields[j].Choices = fieldChoices(choices, tables[i].Hierarchy, f.Name)
+		}
+	}
+	return nil
+}
+
+// hierarchy returns table followed by its ancestors, nearest first.
+func hierarchy(table string, parents map[string]string) []string {
+	var chain []string
+	for name := table; name != "" && !slices.Contains(chain, name); name = parents[name] {
+		chain = append(chain, name)
+	}
+	return chain
+}
+
+// effectiveFields merges the own fields of each table of chain. A field
+// redefined lower in the chain hides the inherited definition.
+func effectiveFields(chain []string, own map[string][]TableField) []TableField {
+	var fields []TableField
+	seen := map[string]bool{}
+	for depth, name := range chain {
+		for _, f := range own[name] {
+			if seen[f.Name] {
+				continue
+			}
+			seen[f.Name] = true
+			f.DefinedOn = name
+			f.Inherited = depth > 0
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,77 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetTablesResolvesInheritanceAcrossScopes(t *testing.T) {
+	objects := map[string]map[string]string{
+		"u_major": {"name": "u_major", "sys_scope.scope": "x_app", "super_class.name": "u_incident", "is_extendable": "false"},
+		"u_incident": {"name": "u_incident", "sys_scope.scope": "x_app", "super_class.name": "task", "is_extendable": "true",
+			"number_ref.prefix": "UINC"},
+		"task": {"name": "task", "sys_scope.scope": "global", "super_class.name": "", "is_extendable": "true"},
+	}
+	fields := map[string][]string{
+		"u_major":    {"impact"},
+		"u_incident": {"u_caller", "short_description"},
+		"task":       {"number", "short_description", "impact"},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("sysparm_query")
+		var result []map[string]string
+		switch {
+		case query == "sys_scope.scope=x_app":
+			result = append(result, objects["u_incident"], objects["u_major"])
+		case strings.HasSuffix(r.URL.Path, "/sys_db_object"):
+			if query != "nameINtask" {
+				t.Errorf("ancestor query = %s", query)
+			}
+			result = append(result, objects["task"])
+		case strings.HasSuffix(r.URL.Path, "/sys_choice"):
+			result = append(result,
+				map[string]string{"name": "task", "element": "impact", "value": "1", "label": "High", "sequence": "1"},
+				map[string]string{"name": "task", "element": "impact", "value": "3", "label": "Low", "sequence": "3"})
+		case strings.HasPrefix(query, "name="):
+			for _, f := range fields[strings.TrimPrefix(query, "name=")] {
+				result = append(result, map[string]string{"element": f, "internal_type": "string"})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	tables, err := c.GetTables(context.Background(), "x_app", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("got %d tables, want the 2 scoped ones", len(tables))
+	}
+	incident, major := tables[0], tables[1]
+	if !incident.Extendable || incident.NumberPrefix != "UINC" || incident.Scope != "x_app" {
+		t.Errorf("u_incident = %+v", incident)
+	}
+	if got := major.HierarchyPath(); got != "u_major -> u_incident -> task -> (base)" {
+		t.Errorf("hierarchy = %s", got)
+	}
+
+	var got []string
+	for _, f := range major.Fields {
+		got = append(got, fmt.Sprintf("%s@%s:%v", f.Name, f.DefinedOn, f.Inherited))
+	}
+	want := "impact@u_major:false,u_caller@u_incident:true,short_description@u_incident:true,number@task:true"
+	if strings.Join(got, ",") != want {
+		t.Errorf("fields = %s, want %s", strings.Join(got, ","), want)
+	}
+	// Choices defined on task apply to the field wherever it is redefined
+	if choices := major.Fields[0].Choices; len(choices) != 2 || choices[1] != (Choice{Value: "3", Label: "Low", Sequence: 3}) {
+		t.Errorf("impact choices = %+v", choices)
+	}
+}
@@ -0,0 +1,205 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AuthMethod selects how a Client authenticates its requests.
+type AuthMethod string
+
+const (
+	// AuthBasic sends the username and password with every request.
+	AuthBasic AuthMethod = "basic"
+	// AuthOAuth sends an OAuth 2.0 bearer token obtained from
+	// /oauth_token.do and refreshes it as it expires.
+	AuthOAuth AuthMethod = "oauth"
+)
+
+// ParseAuthMethod validates an auth method name. An empty name means
+// AuthBasic.
+func ParseAuthMethod(s string) (AuthMethod, error) {
+	switch AuthMethod(strings.ToLower(s)) {
+	case "", AuthBasic:
+		return AuthBasic, nil
+	case AuthOAuth:
+		return AuthOAuth, nil
+	}
+	return "", fmt.Errorf("unknown auth method %q (want basic or oauth)", s)
+}
+
+// tokenEndpoint is the ServiceNow OAuth 2.0 token endpoint.
+const tokenEndpoint = "/oauth_token.do"
+
+// expirySkew refreshes tokens slightly before they expire so a request does
+// not race the expiry on the wire.
+const expirySkew = 30 * time.Second
+
+// Token is an OAuth 2.0 token pair issued by the instance.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// Valid reports whether the access token can still be used.
+func (t *Token) Valid() bool {
+	return t != nil && t.AccessToken != "" &&
+		(t.Expiry.IsZero() || time.Now().Add(expirySkew).Before(t.Expiry))
+}
+
+// tokenResponse is the JSON body returned by /oauth_token.do.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// PasswordGrant exchanges the client's username and password for a token
+// using the OAuth 2.0 resource owner password grant.
+func (c *Client) PasswordGrant(ctx context.Context) error {
+	if c.Username == "" || c.Password == "" {
+		return fmt.Errorf("username and password are required for the password grant")
+	}
+	form := url.Values{}
+	form.Set("grant_type", "password")
+	form.Set("username", c.Username)
+	form.Set("password", c.Password)
+	return c.requestToken(ctx, form)
+}
+
+// refreshToken renews the access token with the stored refresh token.
+func (c *Client) refreshToken(ctx context.Context) error {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", c.Token.RefreshToken)
+	return c.requestToken(ctx, form)
+}
+
+// requestToken posts a grant to the token endpoint and stores the result.
+func (c *Client) requestToken(ctx context.Context, form url.Values) error {
+	form.Set("client_id", c.ClientID)
+	if c.ClientSecret != "" {
+		form.Set("client_secret", c.ClientSecret)
+	}
+
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil || resp.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		msg := tr.ErrorDescription
+		if msg == "" {
+			msg = tr.Error
+		}
+		if msg == "" {
+			msg = string(body)
+		}
+		return &AuthError{Message: msg, Status: resp.StatusCode}
+	}
+
+	token := &Token{
+		AccessToken:  tr.AccessToken,
+		RefreshToken: tr.RefreshToken,
+		TokenType:    tr.TokenType,
+	}
+	if token.RefreshToken == "" && c.Token != nil {
+		// Refresh grants may omit the refresh token when it is not rotated.
+		token.RefreshToken = c.Token.RefreshToken
+	}
+	if tr.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	c.Token = token
+
+	if c.OnToken != nil {
+		c.OnToken(token)
+	}
+	return nil
+}
+
+// accessToken returns a usable access token, refreshing or re-granting it
+// when the cached one has expired.
+func (c *Client) accessToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.Token.Valid() {
+		return c.Token.AccessToken, nil
+	}
+
+	var err error
+	switch {
+	case c.Token != nil && c.Token.RefreshToken != "":
+		err = c.refreshToken(ctx)
+		if err != nil && c.Password != "" {
+			err = c.PasswordGrant(ctx)
+		}
+	case c.Password != "":
+		err = c.PasswordGrant(ctx)
+	default:
+		err = &AuthError{Message: "no OAuth token available, run 'connect' again", Status: http.StatusUnauthorized}
+	}
+	if err != nil {
+		return "", err
+	}
+	return c.Token.AccessToken, nil
+}
+
+// expireToken drops the cached access token so the next request refreshes
+// it. It is used when the instance rejects a token we believed was valid.
+// A token another request has refreshed since rejected was sent is kept.
+func (c *Client) expireToken(rejected string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.Token != nil && c.Token.AccessToken == rejected {
+		expired := *c.Token
+		expired.AccessToken = ""
+		c.Token = &expired
+	}
+}
+
+// authorize sets the Authorization header for the client's auth method.
+func (c *Client) authorize(ctx context.Context, req *http.Request) error {
+	if c.Auth != AuthOAuth {
+		req.SetBasicAuth(c.Username, c.Password)
+		return nil
+	}
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
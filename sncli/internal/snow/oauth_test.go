@@ -0,0 +1,72 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOAuthRefreshesExpiredToken(t *testing.T) {
+	var grants []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == tokenEndpoint {
+			r.ParseForm()
+			grants = append(grants, r.PostForm.Get("grant_type"))
+			if r.PostForm.Get("client_id") != "id" || r.PostForm.Get("client_secret") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(tokenResponse{
+				AccessToken:  "fresh",
+				RefreshToken: "refresh-2",
+				ExpiresIn:    1800,
+			})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"result":[]}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewOAuthClient(srv.URL, "id", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Token = &Token{AccessToken: "stale", RefreshToken: "refresh-1", Expiry: time.Now().Add(-time.Minute)}
+
+	var saved *Token
+	c.OnToken = func(tok *Token) { saved = tok }
+
+	if _, err := c.Request(context.Background(), "GET", "/api/now/table/sys_user", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 1 || grants[0] != "refresh_token" {
+		t.Errorf("grants = %v, want [refresh_token]", grants)
+	}
+	if saved == nil || saved.RefreshToken != "refresh-2" {
+		t.Errorf("OnToken got %+v, want rotated refresh token", saved)
+	}
+}
+
+func TestExpireTokenKeepsRefreshedToken(t *testing.T) {
+	c, err := NewOAuthClient("https://dev.example.com", "id", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another request refreshed the token after "old" was rejected
+	c.Token = &Token{AccessToken: "new", RefreshToken: "refresh", Expiry: time.Now().Add(time.Hour)}
+	c.expireToken("old")
+	if !c.Token.Valid() {
+		t.Errorf("refreshed token expired: %+v", c.Token)
+	}
+	c.expireToken("new")
+	if c.Token.Valid() || c.Token.RefreshToken != "refresh" {
+		t.Errorf("rejected token kept: %+v", c.Token)
+	}
+}
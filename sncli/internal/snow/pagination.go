@@ -0,0 +1,250 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultPageSize is the number of records requested per page when the
+	// client does not set PageSize.
+	DefaultPageSize = 1000
+	// DefaultMaxRecords is the hard cap on records a single query may return
+	// when the client does not set MaxRecords.
+	DefaultMaxRecords = 100000
+)
+
+// ErrMaxRecords is returned by a Pager when a query has more records than the
+// client's MaxRecords allows. Records fetched up to the cap are still
+// available from the pages already returned.
+var ErrMaxRecords = errors.New("query exceeds the maximum number of records")
+
+var linkNextRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// Pager iterates over the pages of a Table API query, following the Link
+// header when the instance sends one and falling back to sysparm_offset
+// arithmetic against X-Total-Count otherwise.
+//
+//	p := c.NewPager(ctx, "sys_db_object", params)
+//	for p.Next() {
+//	    for _, raw := range p.Records() { ... }
+//	}
+//	if err := p.Err(); err != nil { ... }
+type Pager struct {
+	ctx     context.Context
+	client  *Client
+	next    string
+	limit   int
+	max     int
+	take    int
+	fetched int
+	total   int
+	records []json.RawMessage
+	err     error
+	done    bool
+}
+
+// NewPager returns a Pager for the given table and query parameters.
+// sysparm_limit and sysparm_offset are managed by the pager and any values
+// already present in params are overridden.
+func (c *Client) NewPager(ctx context.Context, table string, params url.Values) *Pager {
+	q := url.Values{}
+	for k, v := range params {
+		q[k] = v
+	}
+	limit := c.pageSize()
+	q.Set("sysparm_limit", strconv.Itoa(limit))
+	q.Set("sysparm_offset", "0")
+
+	return &Pager{
+		ctx:    ctx,
+		client: c,
+		next:   "/api/now/table/" + url.PathEscape(table) + "?" + q.Encode(),
+		limit:  limit,
+		max:    c.maxRecords(),
+		total:  -1,
+	}
+}
+
+// Limit stops the pager after n records without reporting ErrMaxRecords,
+// for callers that only want the first n. It must be called before Next;
+// n <= 0 means no limit.
+func (p *Pager) Limit(n int) *Pager {
+	if n <= 0 {
+		return p
+	}
+	p.take = n
+	if n < p.limit {
+		p.limit = n
+		if u, err := url.Parse(p.next); err == nil {
+			q := u.Query()
+			q.Set("sysparm_limit", strconv.Itoa(n))
+			u.RawQuery = q.Encode()
+			p.next = u.String()
+		}
+	}
+	return p
+}
+
+// Next fetches the next page. It returns false when there are no more pages
+// or an error occurred; check Err to tell them apart.
+func (p *Pager) Next() bool {
+	// Security rules can hide every row of a page that is not the last
+	for !p.done && p.err == nil {
+		if p.fetch() {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch requests the page at p.next and reports whether it holds records.
+func (p *Pager) fetch() bool {
+	if p.take > 0 && p.fetched >= p.take {
+		p.done = true
+		return false
+	}
+	if p.fetched >= p.max {
+		p.done = true
+		if p.total < 0 || p.total > p.fetched {
+			p.err = fmt.Errorf("%w (%d)", ErrMaxRecords, p.max)
+		}
+		return false
+	}
+
+	data, header, err := p.client.do(p.ctx, "GET", p.next, nil)
+	if err != nil {
+		p.err = err
+		return false
+	}
+
+	var response struct {
+		Result []json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		p.err = fmt.Errorf("failed to parse page: %w", err)
+		return false
+	}
+
+	p.records = response.Result
+	remaining := p.max - p.fetched
+	if p.take > 0 && p.take-p.fetched < remaining {
+		remaining = p.take - p.fetched
+	}
+	if len(p.records) > remaining {
+		p.records = p.records[:remaining]
+	}
+	p.fetched += len(p.records)
+
+	if total, err := strconv.Atoi(header.Get("X-Total-Count")); err == nil {
+		p.total = total
+	}
+	p.advance(header, len(response.Result))
+
+	return len(p.records) > 0
+}
+
+// advance works out the endpoint of the following page, or marks the pager
+// done when the current page was the last one.
+func (p *Pager) advance(header http.Header, n int) {
+	if next := nextLink(header, p.client.BaseURL); next != "" {
+		p.next = next
+		return
+	}
+
+	u, err := url.Parse(p.next)
+	if err != nil {
+		p.err = fmt.Errorf("failed to parse page endpoint: %w", err)
+		return
+	}
+	q := u.Query()
+	// ACLs and before-query rules drop rows from a page after the instance
+	// has applied the offset, so a page spans its limit even when short.
+	offset, _ := strconv.Atoi(q.Get("sysparm_offset"))
+	offset += p.limit
+
+	switch {
+	case p.total >= 0:
+		if p.fetched >= p.total || offset >= p.total {
+			p.done = true
+			return
+		}
+	case n < p.limit:
+		// Without a total, a short page is the only sign of the last one
+		p.done = true
+		return
+	}
+
+	q.Set("sysparm_offset", strconv.Itoa(offset))
+	u.RawQuery = q.Encode()
+	p.next = u.String()
+}
+
+// Records returns the records of the current page.
+func (p *Pager) Records() []json.RawMessage {
+	return p.records
+}
+
+// Total returns the X-Total-Count reported by the instance, or -1 if it has
+// not been sent.
+func (p *Pager) Total() int {
+	return p.total
+}
+
+// Err returns the first error encountered while paging.
+func (p *Pager) Err() error {
+	return p.err
+}
+
+// nextLink extracts the rel="next" entry of a Link header as an endpoint
+// relative to baseURL so it can be passed back to Client.do.
+func nextLink(header http.Header, baseURL string) string {
+	for _, link := range header.Values("Link") {
+		m := linkNextRe.FindStringSubmatch(link)
+		if m == nil {
+			continue
+		}
+		if strings.HasPrefix(m[1], baseURL+"/") {
+			return strings.TrimPrefix(m[1], baseURL)
+		}
+		u, err := url.Parse(m[1])
+		if err != nil {
+			return ""
+		}
+		return u.RequestURI()
+	}
+	return ""
+}
+
+// collect drains p into a slice of T.
+func collect[T any](p *Pager) ([]T, error) {
+	var out []T
+	for p.Next() {
+		records, err := decodeRecords[T](p.Records())
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, records...)
+	}
+	return out, p.Err()
+}
+
+// decodeRecords parses raw Table API records into T.
+func decodeRecords[T any](raws []json.RawMessage) ([]T, error) {
+	out := make([]T, 0, len(raws))
+	for _, raw := range raws {
+		var v T
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return nil, fmt.Errorf("failed to parse record: %w", err)
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
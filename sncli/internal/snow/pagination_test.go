@@ -0,0 +1,148 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// tableServer serves n sys_db_object records, paging on sysparm_limit and
+// sysparm_offset. When link is set it also sends a Link header.
+func tableServer(t *testing.T, n int, link bool) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("sysparm_limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("sysparm_offset"))
+
+		var result []Table
+		for i := offset; i < n && i < offset+limit; i++ {
+			result = append(result, Table{Name: fmt.Sprintf("u_table_%d", i)})
+		}
+
+		w.Header().Set("X-Total-Count", strconv.Itoa(n))
+		if link && offset+limit < n {
+			q := r.URL.Query()
+			q.Set("sysparm_offset", strconv.Itoa(offset+limit))
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s?%s>;rel="next"`, srv.URL, r.URL.Path, q.Encode()))
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testClient(t *testing.T, srv *httptest.Server) *Client {
+	t.Helper()
+	c, err := NewClient(srv.URL, "admin", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPagerFetchesAllPages(t *testing.T) {
+	for _, link := range []bool{true, false} {
+		t.Run(fmt.Sprintf("link=%v", link), func(t *testing.T) {
+			c := testClient(t, tableServer(t, 25, link))
+			c.PageSize = 10
+
+			tables, err := collect[Table](c.NewPager(context.Background(), "sys_db_object", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tables) != 25 {
+				t.Fatalf("got %d tables, want 25", len(tables))
+			}
+			if tables[24].Name != "u_table_24" {
+				t.Errorf("last table = %q, want u_table_24", tables[24].Name)
+			}
+		})
+	}
+}
+
+func TestPagerShortPages(t *testing.T) {
+	// Security constraints hide every third record from the pages
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("sysparm_limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("sysparm_offset"))
+		result := []Table{}
+		for i := offset; i < 25 && i < offset+limit; i++ {
+			if i%3 != 0 {
+				result = append(result, Table{Name: fmt.Sprintf("u_table_%d", i)})
+			}
+		}
+		w.Header().Set("X-Total-Count", "25")
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+	c := testClient(t, srv)
+	c.PageSize = 10
+
+	tables, err := collect[Table](c.NewPager(context.Background(), "sys_db_object", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 16 || tables[15].Name != "u_table_23" {
+		t.Errorf("got %d tables ending with %+v, want 16 ending with u_table_23", len(tables), tables[len(tables)-1])
+	}
+}
+
+func TestPagerEmptyPage(t *testing.T) {
+	// Security constraints hide every record of the second page
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("sysparm_limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("sysparm_offset"))
+		result := []Table{}
+		for i := offset; i < 25 && i < offset+limit; i++ {
+			if i < 10 || i >= 20 {
+				result = append(result, Table{Name: fmt.Sprintf("u_table_%d", i)})
+			}
+		}
+		w.Header().Set("X-Total-Count", "25")
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+	c := testClient(t, srv)
+	c.PageSize = 10
+
+	tables, err := collect[Table](c.NewPager(context.Background(), "sys_db_object", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 15 || tables[14].Name != "u_table_24" {
+		t.Errorf("got %d tables, want 15 ending with u_table_24", len(tables))
+	}
+}
+
+func TestPagerMaxRecords(t *testing.T) {
+	c := testClient(t, tableServer(t, 25, false))
+	c.PageSize = 10
+	c.MaxRecords = 15
+
+	tables, err := collect[Table](c.NewPager(context.Background(), "sys_db_object", nil))
+	if !errors.Is(err, ErrMaxRecords) {
+		t.Fatalf("err = %v, want ErrMaxRecords", err)
+	}
+	if len(tables) != 15 {
+		t.Errorf("got %d tables, want 15", len(tables))
+	}
+}
+
+func TestListRecordsLimit(t *testing.T) {
+	c := testClient(t, tableServer(t, 25, true))
+	c.PageSize = 10
+
+	records, err := c.ListRecords(context.Background(), "sys_db_object", ListOptions{Limit: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 12 {
+		t.Errorf("got %d records, want 12", len(records))
+	}
+}
@@ -0,0 +1,183 @@
+package snow
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// profileTopValues is the number of most frequent values a deep profile
+// keeps per field.
+const profileTopValues = 5
+
+// DataProfile describes the records of a table.
+type DataProfile struct {
+	Records int            `json:"records"`
+	Fields  []FieldProfile `json:"fields,omitempty"`
+}
+
+// FieldProfile describes the values of a field across the records of a
+// table.
+type FieldProfile struct {
+	Field    string  `json:"field"`
+	Filled   int     `json:"filled"`
+	FillRate float64 `json:"fill_rate"`
+	// Distinct is the number of different non-empty values, counted for
+	// choice and reference fields.
+	Distinct *int `json:"distinct,omitempty"`
+	// Min and Max are the range of date fields, and in deep profiles of
+	// numeric fields too.
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+	// Top holds the most frequent values of counted fields in deep
+	// profiles.
+	Top []ValueCount `json:"top,omitempty"`
+}
+
+// ValueCount is a field value and the number of records holding it.
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// numericTypes are the sys_dictionary internal types holding numbers.
+var numericTypes = map[string]bool{
+	"integer": true, "longint": true, "decimal": true, "float": true, "currency": true, "price": true,
+}
+
+// profiledField reports whether the values of f live on the records of its
+// table. Journal fields are stored in sys_journal_field instead.
+func profiledField(f TableField) bool {
+	return !strings.HasPrefix(f.Type, "journal")
+}
+
+// countedField reports whether the distinct values of f are counted.
+func countedField(f TableField, deep bool) bool {
+	return f.Type == string(Reference) || len(f.Choices) > 0 || deep && f.Type == "boolean"
+}
+
+// rangedField reports whether the minimum and maximum of f are taken.
+func rangedField(f TableField, deep bool) bool {
+	return dateTypes[f.Type] || deep && numericTypes[f.Type]
+}
+
+// ProfileTables sets the Profile of every table through the Aggregate API:
+// its record count and, for tables with their fields loaded, the fill rate
+// of each field, the distinct values of choice and reference fields and
+// the range of date fields. A deep profile also counts boolean fields,
+// takes the range of numeric fields and keeps the most frequent values of
+// counted fields.
+func (c *Client) ProfileTables(ctx context.Context, tables []Table, deep bool) error {
+	// What each query answers for which table and field
+	type target struct {
+		table, field int
+		kind         string
+	}
+	var queries []statsQuery
+	var targets []target
+	add := func(t target, table string, params url.Values) {
+		params.Set("sysparm_count", "true")
+		queries = append(queries, statsQuery{table: table, params: params})
+		targets = append(targets, t)
+	}
+
+	for i, t := range tables {
+		var ranged []string
+		for _, f := range t.Fields {
+			if profiledField(f) && rangedField(f, deep) {
+				ranged = append(ranged, f.Name)
+			}
+		}
+		count := url.Values{}
+		if len(ranged) > 0 {
+			count.Set("sysparm_min_fields", strings.Join(ranged, ","))
+			count.Set("sysparm_max_fields", strings.Join(ranged, ","))
+		}
+		add(target{table: i, field: -1, kind: "count"}, t.Name, count)
+
+		for j, f := range t.Fields {
+			if !profiledField(f) {
+				continue
+			}
+			filled := url.Values{}
+			filled.Set("sysparm_query", NewQuery().Where(f.Name, IsNotEmpty).String())
+			add(target{table: i, field: j, kind: "filled"}, t.Name, filled)
+			if countedField(f, deep) {
+				grouped := url.Values{}
+				grouped.Set("sysparm_group_by", f.Name)
+				add(target{table: i, field: j, kind: "distinct"}, t.Name, grouped)
+			}
+		}
+	}
+
+	results, err := c.aggregateEach(ctx, "profiles", queries)
+	if err != nil {
+		return err
+	}
+
+	profiles := make([]DataProfile, len(tables))
+	fields := make([]map[int]*FieldProfile, len(tables))
+	for i := range fields {
+		fields[i] = map[int]*FieldProfile{}
+	}
+	field := func(t target) *FieldProfile {
+		p, ok := fields[t.table][t.field]
+		if !ok {
+			p = &FieldProfile{Field: tables[t.table].Fields[t.field].Name}
+			fields[t.table][t.field] = p
+		}
+		return p
+	}
+	for k, t := range targets {
+		stats := results[k]
+		switch t.kind {
+		case "count":
+			if len(stats) == 0 {
+				continue
+			}
+			profiles[t.table].Records = stats[0].count()
+			for j, f := range tables[t.table].Fields {
+				if v, ok := stats[0].Stats.Min[f.Name]; ok {
+					field(target{table: t.table, field: j}).Min = v
+				}
+				if v, ok := stats[0].Stats.Max[f.Name]; ok {
+					field(target{table: t.table, field: j}).Max = v
+				}
+			}
+		case "filled":
+			if len(stats) > 0 {
+				field(t).Filled = stats[0].count()
+			}
+		case "distinct":
+			var values []ValueCount
+			for _, s := range stats {
+				if len(s.GroupBy) > 0 && s.GroupBy[0].Value != "" {
+					values = append(values, ValueCount{Value: s.GroupBy[0].Value, Count: s.count()})
+				}
+			}
+			distinct := len(values)
+			p := field(t)
+			p.Distinct = &distinct
+			if deep {
+				sort.SliceStable(values, func(a, b int) bool { return values[a].Count > values[b].Count })
+				p.Top = values[:min(profileTopValues, len(values))]
+			}
+		}
+	}
+
+	for i := range tables {
+		for j := range tables[i].Fields {
+			p, ok := fields[i][j]
+			if !ok {
+				continue
+			}
+			if records := profiles[i].Records; records > 0 {
+				p.FillRate = float64(p.Filled) / float64(records)
+			}
+			profiles[i].Fields = append(profiles[i].Fields, *p)
+		}
+		tables[i].Profile = &profiles[i]
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProfileTables(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == batchEndpoint {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path != "/api/now/stats/u_order" || r.URL.Query().Get("sysparm_count") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		q := r.URL.Query()
+		var result any
+		switch {
+		case q.Get("sysparm_group_by") == "u_state":
+			result = []map[string]any{
+				{"stats": map[string]string{"count": "3"}, "groupby_fields": []map[string]string{{"field": "u_state", "value": "1"}}},
+				{"stats": map[string]string{"count": "6"}, "groupby_fields": []map[string]string{{"field": "u_state", "value": "2"}}},
+				{"stats": map[string]string{"count": "1"}, "groupby_fields": []map[string]string{{"field": "u_state", "value": ""}}},
+			}
+		case q.Get("sysparm_query") == "u_stateISNOTEMPTY":
+			result = map[string]any{"stats": map[string]string{"count": "9"}}
+		case q.Get("sysparm_query") == "u_dueISNOTEMPTY":
+			result = map[string]any{"stats": map[string]string{"count": "5"}}
+		case q.Get("sysparm_query") == "":
+			if q.Get("sysparm_min_fields") != "u_due" || q.Get("sysparm_max_fields") != "u_due" {
+				t.Errorf("count request = %s", r.URL)
+			}
+			result = map[string]any{"stats": map[string]any{
+				"count": "10",
+				"min":   map[string]string{"u_due": "2024-01-01 00:00:00"},
+				"max":   map[string]string{"u_due": "2024-06-30 00:00:00"},
+			}}
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+
+	tables := []Table{{Name: "u_order", Fields: []TableField{
+		{Name: "u_state", Type: "string", Choices: []Choice{{Value: "1"}, {Value: "2"}}},
+		{Name: "u_due", Type: "glide_date_time"},
+		{Name: "u_notes", Type: "journal_input"},
+	}}}
+	c := testClient(t, srv)
+	if err := c.ProfileTables(context.Background(), tables, true); err != nil {
+		t.Fatal(err)
+	}
+
+	p := tables[0].Profile
+	if p == nil || p.Records != 10 || len(p.Fields) != 2 {
+		t.Fatalf("profile = %+v", p)
+	}
+	state, due := p.Fields[0], p.Fields[1]
+	if state.Filled != 9 || state.FillRate != 0.9 || state.Distinct == nil || *state.Distinct != 2 {
+		t.Errorf("u_state = %+v", state)
+	}
+	if len(state.Top) != 2 || state.Top[0] != (ValueCount{Value: "2", Count: 6}) {
+		t.Errorf("u_state top values = %+v", state.Top)
+	}
+	if due.FillRate != 0.5 || due.Distinct != nil || !strings.HasPrefix(due.Min, "2024-01-01") || !strings.HasPrefix(due.Max, "2024-06-30") {
+		t.Errorf("u_due = %+v", due)
+	}
+}
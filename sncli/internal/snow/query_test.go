@@ -0,0 +1,56 @@
+package snow
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQueryString(t *testing.T) {
+	tests := []struct {
+		q    *Query
+		want string
+	}{
+		{NewQuery(), ""},
+		{NewQuery().Eq("active", "true"), "active=true"},
+		{
+			NewQuery().Eq("active", "true").Or("priority", In, "1", "2").Where("assigned_to", IsEmpty),
+			"active=true^ORpriorityIN1,2^assigned_toISEMPTY",
+		},
+		{
+			NewQuery().Where("short_description", Like, "disk").NQ().Where("number", StartsWith, "INC").NQ(),
+			"short_descriptionLIKEdisk^NQnumberSTARTSWITHINC",
+		},
+		{
+			NewQuery().Where("sys_updated_on", RelativeGT, Ago(2, Hour)).OrderByDesc("sys_updated_on").OrderBy("number"),
+			"sys_updated_onRELATIVEGT@hour@ago@2^ORDERBYDESCsys_updated_on^ORDERBYnumber",
+		},
+		{NewQuery().OrderBy("name"), "ORDERBYname"},
+		{NewQuery().Where("opened_at", Between, "2024-01-01", "2024-02-01"), "opened_atBETWEEN2024-01-01@2024-02-01"},
+		{NewQuery().Eq("name", "a^ORactive=false"), "name=a^^ORactive=false"},
+	}
+	for _, tt := range tests {
+		if got := tt.q.String(); got != tt.want {
+			t.Errorf("query = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestAuthenticateEncodesQuery(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.Query().Get("sysparm_query")
+		w.Write([]byte(`{"result":[{"user_name":"o'brien&co^x"}]}`))
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	c.Username = "o'brien&co^x"
+	if _, err := c.Authenticate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := "user_name=o'brien&co^^x"; got != want {
+		t.Errorf("sysparm_query = %q, want %q", got, want)
+	}
+}
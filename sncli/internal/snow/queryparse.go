@@ -0,0 +1,453 @@
+package snow
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ParsedQuery is the syntax tree of an encoded query: queries joined with
+// ^NQ, each an AND of clauses, each clause an OR of conditions.
+type ParsedQuery struct {
+	Queries []QueryGroup `json:"queries"`
+	Order   []OrderTerm  `json:"order,omitempty"`
+}
+
+// QueryGroup is one query of a ^NQ union. A record matches when every
+// clause matches.
+type QueryGroup struct {
+	Clauses []QueryClause `json:"clauses"`
+}
+
+// QueryClause is a list of conditions joined with ^OR. In encoded queries
+// ^OR binds tighter than ^, so a^ORb^c means (a OR b) AND c.
+type QueryClause struct {
+	Any []Condition `json:"any"`
+}
+
+// Condition is a single field comparison.
+type Condition struct {
+	Field    string   `json:"field"`
+	Operator Operator `json:"operator"`
+	Value    string   `json:"value,omitempty"`
+}
+
+// OrderTerm is an ORDERBY or ORDERBYDESC clause.
+type OrderTerm struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// QueryError lists what is wrong with an encoded query.
+type QueryError struct {
+	Query    string
+	Problems []string
+}
+
+func (e *QueryError) Error() string {
+	return "invalid query: " + strings.Join(e.Problems, "; ")
+}
+
+// operators holds every operator the parser accepts, longest first so that
+// NOT LIKE wins over LIKE and != over =.
+var operators = func() []Operator {
+	ops := []Operator{
+		Equals, NotEquals, GreaterThan, GreaterOrEq, LessThan, LessOrEq,
+		In, NotIn, Like, NotLike, StartsWith, EndsWith, IsEmpty, IsNotEmpty,
+		EmptyString, Anything, Between, SameAs, NotSameAs, On, NotOn,
+		RelativeGT, RelativeGE, RelativeLT, RelativeLE, RelativeEE, DynamicOn,
+		ChangesFrom, ChangesTo, ValChanges, InHierarchy, InstanceOf,
+		MatchesPattern, MatchesRegex,
+		GreaterThanField, LessThanField, GreaterOrEqField, LessOrEqField,
+		"DATEPART", "MORETHAN", "LESSTHAN",
+	}
+	sort.SliceStable(ops, func(i, j int) bool { return len(ops[i]) > len(ops[j]) })
+	return ops
+}()
+
+// ParseQuery parses an encoded query. It rejects clauses ServiceNow would
+// silently ignore, such as unknown operators or missing field names.
+func ParseQuery(query string) (*ParsedQuery, error) {
+	parsed := &ParsedQuery{}
+	group := &QueryGroup{}
+	var problems []string
+
+	for i, segment := range splitQuery(query) {
+		join := "^"
+		switch {
+		case segment == "" || segment == "EQ":
+			continue
+		case strings.HasPrefix(segment, "ORDERBYDESC"):
+			parsed.Order = append(parsed.Order, OrderTerm{Field: segment[len("ORDERBYDESC"):], Desc: true})
+			continue
+		case strings.HasPrefix(segment, "ORDERBY"):
+			parsed.Order = append(parsed.Order, OrderTerm{Field: segment[len("ORDERBY"):]})
+			continue
+		case i > 0 && strings.HasPrefix(segment, "NQ"):
+			if len(group.Clauses) > 0 {
+				parsed.Queries = append(parsed.Queries, *group)
+			}
+			group = &QueryGroup{}
+			segment = segment[len("NQ"):]
+		case i > 0 && strings.HasPrefix(segment, "OR"):
+			join = "^OR"
+			segment = segment[len("OR"):]
+		}
+
+		cond, err := parseCondition(segment)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if join == "^OR" && len(group.Clauses) > 0 {
+			last := &group.Clauses[len(group.Clauses)-1]
+			last.Any = append(last.Any, cond)
+		} else {
+			group.Clauses = append(group.Clauses, QueryClause{Any: []Condition{cond}})
+		}
+	}
+	if len(group.Clauses) > 0 {
+		parsed.Queries = append(parsed.Queries, *group)
+	}
+	for _, o := range parsed.Order {
+		if !validFieldName(o.Field) {
+			problems = append(problems, fmt.Sprintf("bad ORDERBY field %q", o.Field))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &QueryError{Query: query, Problems: problems}
+	}
+	return parsed, nil
+}
+
+// splitQuery splits an encoded query on ^, keeping ^^ as a literal caret.
+func splitQuery(query string) []string {
+	var segments []string
+	var b strings.Builder
+	for i := 0; i < len(query); i++ {
+		if query[i] != '^' {
+			b.WriteByte(query[i])
+			continue
+		}
+		if i+1 < len(query) && query[i+1] == '^' {
+			b.WriteByte('^')
+			i++
+			continue
+		}
+		segments = append(segments, b.String())
+		b.Reset()
+	}
+	return append(segments, b.String())
+}
+
+// TextQueryField is the pseudo-field of keyword searches across the text
+// indexed fields of a table, as in 123TEXTQUERY321=network.
+const TextQueryField = "123TEXTQUERY321"
+
+// pseudoField returns the keyword search pseudo-field s starts with:
+// TextQueryField or an IR_ field such as IR_AND_OR_QUERY. It returns ""
+// when s starts with a plain field name.
+func pseudoField(s string) string {
+	if strings.HasPrefix(s, TextQueryField) {
+		return TextQueryField
+	}
+	if !strings.HasPrefix(s, "IR_") {
+		return ""
+	}
+	end := len("IR_")
+	for end < len(s) && (s[end] >= 'A' && s[end] <= 'Z' || s[end] == '_') {
+		end++
+	}
+	return s[:end]
+}
+
+// parseCondition parses field, operator and value from a condition such as
+// priorityIN1,2.
+func parseCondition(s string) (Condition, error) {
+	field := pseudoField(s)
+	if field == "" {
+		end := 0
+		for end < len(s) && isFieldChar(s[end]) {
+			end++
+		}
+		field = s[:end]
+	}
+	rest := s[len(field):]
+	if field == "" {
+		return Condition{}, fmt.Errorf("condition %q has no field name", s)
+	}
+	for _, op := range operators {
+		if !strings.HasPrefix(rest, string(op)) {
+			continue
+		}
+		value := rest[len(op):]
+		if op.unary() && value != "" {
+			return Condition{}, fmt.Errorf("%s takes no value in %q", op, s)
+		}
+		if pseudoField(field) == "" && !validFieldName(field) {
+			return Condition{}, fmt.Errorf("bad field name %q", field)
+		}
+		return Condition{Field: field, Operator: op, Value: value}, nil
+	}
+	if rest == "" {
+		return Condition{}, fmt.Errorf("condition %q has no operator", s)
+	}
+	return Condition{}, fmt.Errorf("unknown operator in %q", s)
+}
+
+func isFieldChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '.'
+}
+
+// validFieldName reports whether name is a field or dot-walked field path.
+func validFieldName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, part := range strings.Split(name, ".") {
+		if part == "" {
+			return false
+		}
+		for i := 0; i < len(part); i++ {
+			if !isFieldChar(part[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// String returns the encoded form of the query.
+func (p *ParsedQuery) String() string {
+	q := NewQuery()
+	for i, group := range p.Queries {
+		if i > 0 {
+			q.NQ()
+		}
+		for _, clause := range group.Clauses {
+			for j, c := range clause.Any {
+				add := q.Where
+				if j > 0 {
+					add = q.Or
+				}
+				if c.Operator.unary() {
+					add(c.Field, c.Operator)
+				} else {
+					add(c.Field, c.Operator, c.Value)
+				}
+			}
+		}
+	}
+	for _, o := range p.Order {
+		if o.Desc {
+			q.OrderByDesc(o.Field)
+		} else {
+			q.OrderBy(o.Field)
+		}
+	}
+	return q.String()
+}
+
+// Pretty returns the query as indented, readable text:
+//
+//	WHERE (active = true OR priority IN 1,2)
+//	  AND assigned_to ISEMPTY
+//	ORDER BY sys_updated_on DESC
+func (p *ParsedQuery) Pretty() string {
+	var b strings.Builder
+	for i, group := range p.Queries {
+		if i > 0 {
+			b.WriteString("UNION\n")
+		}
+		for j, clause := range group.Clauses {
+			if j == 0 {
+				b.WriteString("WHERE ")
+			} else {
+				b.WriteString("  AND ")
+			}
+			b.WriteString(clause.String())
+			b.WriteByte('\n')
+		}
+	}
+	if len(p.Queries) == 0 {
+		b.WriteString("(all records)\n")
+	}
+	if len(p.Order) > 0 {
+		terms := make([]string, len(p.Order))
+		for i, o := range p.Order {
+			terms[i] = o.Field
+			if o.Desc {
+				terms[i] += " DESC"
+			}
+		}
+		b.WriteString("ORDER BY " + strings.Join(terms, ", ") + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// String returns the clause as readable text.
+func (c QueryClause) String() string {
+	parts := make([]string, len(c.Any))
+	for i, cond := range c.Any {
+		parts[i] = cond.String()
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, " OR ") + ")"
+}
+
+// String returns the condition as readable text.
+func (c Condition) String() string {
+	if c.Operator.unary() {
+		return c.Field + " " + string(c.Operator)
+	}
+	return c.Field + " " + string(c.Operator) + " " + c.Value
+}
+
+// dateOperators only apply to date and date/time fields.
+var dateOperators = map[Operator]bool{
+	On: true, NotOn: true, "DATEPART": true,
+	RelativeGT: true, RelativeGE: true, RelativeLT: true, RelativeLE: true, RelativeEE: true,
+}
+
+// dateTypes are the sys_dictionary internal types holding dates.
+var dateTypes = map[string]bool{
+	"glide_date": true, "glide_date_time": true, "due_date": true, "date": true,
+	"datetime": true, "glide_time": true, "calendar_date_time": true, "integer_date": true,
+}
+
+// ValidateQuery parses query and checks its field names, dot-walks and
+// operators against the sys_dictionary entries of table and the tables it
+// extends. Problems are reported together in a *QueryError.
+func (c *Client) ValidateQuery(ctx context.Context, table, query string) (*ParsedQuery, error) {
+	parsed, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &queryValidator{client: c, fields: map[string]map[string]TableField{}}
+	var problems []string
+	for _, group := range parsed.Queries {
+		for _, clause := range group.Clauses {
+			for _, cond := range clause.Any {
+				if pseudoField(cond.Field) != "" {
+					// Keyword searches have no dictionary entry
+					continue
+				}
+				field, err := v.resolve(ctx, table, cond.Field)
+				if err != nil {
+					return nil, err
+				}
+				switch {
+				case field == nil:
+					problems = append(problems, fmt.Sprintf("%s has no field %q", table, cond.Field))
+				case dateOperators[cond.Operator] && !dateTypes[field.Type]:
+					problems = append(problems, fmt.Sprintf("%s needs a date field, %s is %s", cond.Operator, cond.Field, field.Type))
+				case cond.Operator == DynamicOn && field.Reference == "":
+					problems = append(problems, fmt.Sprintf("%s needs a reference field, %s is %s", cond.Operator, cond.Field, field.Type))
+				}
+			}
+		}
+	}
+	for _, o := range parsed.Order {
+		field, err := v.resolve(ctx, table, o.Field)
+		if err != nil {
+			return nil, err
+		}
+		if field == nil {
+			problems = append(problems, fmt.Sprintf("%s has no field %q to order by", table, o.Field))
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &QueryError{Query: query, Problems: problems}
+	}
+	return parsed, nil
+}
+
+// queryValidator resolves field paths, caching each table's fields.
+type queryValidator struct {
+	client *Client
+	fields map[string]map[string]TableField
+}
+
+// resolve returns the field a possibly dot-walked path names on table, or
+// nil if there is none.
+func (v *queryValidator) resolve(ctx context.Context, table, path string) (*TableField, error) {
+	name, rest, walk := strings.Cut(path, ".")
+	fields, ok := v.fields[table]
+	if !ok {
+		var err error
+		if fields, err = v.client.allFields(ctx, table); err != nil {
+			return nil, err
+		}
+		v.fields[table] = fields
+	}
+
+	field, ok := fields[name]
+	if !ok {
+		return nil, nil
+	}
+	if !walk {
+		return &field, nil
+	}
+	if field.Reference == "" {
+		return nil, nil
+	}
+	return v.resolve(ctx, field.Reference, rest)
+}
+
+// allFields returns the fields of table and the tables it extends, keyed by
+// name.
+func (c *Client) allFields(ctx context.Context, table string) (map[string]TableField, error) {
+	chain, err := c.tableChain(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]TableField{}
+	for _, t := range chain {
+		own, err := c.getTableFields(ctx, t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch fields for table %s: %w", t, err)
+		}
+		for _, f := range own {
+			// Fields of the table itself override inherited ones
+			if _, ok := fields[f.Name]; !ok && f.Name != "" {
+				fields[f.Name] = f
+			}
+		}
+	}
+	return fields, nil
+}
+
+// tableChain returns table followed by the tables it extends, nearest
+// first.
+func (c *Client) tableChain(ctx context.Context, table string) ([]string, error) {
+	var chain []string
+	seen := map[string]bool{}
+	for name := table; name != "" && !seen[name]; {
+		seen[name] = true
+		params := url.Values{}
+		params.Set("sysparm_query", NewQuery().Eq("name", name).String())
+		params.Set("sysparm_fields", "name,super_class.name")
+		params.Set("sysparm_exclude_reference_link", "true")
+
+		rows, err := collectCached[map[string]string](ctx, c, "sys_db_object", params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up table %s: %w", name, err)
+		}
+		if len(rows) == 0 {
+			if name == table {
+				return nil, &QueryError{Problems: []string{fmt.Sprintf("table %q not found", table)}}
+			}
+			break
+		}
+		chain = append(chain, name)
+		name = rows[0]["super_class.name"]
+	}
+	return chain, nil
+}
@@ -0,0 +1,148 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	query := "active=true^ORpriorityIN1,2^assigned_toISEMPTY^NQshort_descriptionLIKEa^^b^ORDERBYDESCsys_updated_on"
+	parsed, err := ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "WHERE (active = true OR priority IN 1,2)\n" +
+		"  AND assigned_to ISEMPTY\n" +
+		"UNION\n" +
+		"WHERE short_description LIKE a^b\n" +
+		"ORDER BY sys_updated_on DESC"
+	if got := parsed.Pretty(); got != want {
+		t.Errorf("Pretty() =\n%s\nwant:\n%s", got, want)
+	}
+	if got := parsed.String(); got != query {
+		t.Errorf("String() = %q, want %q", got, query)
+	}
+}
+
+func TestParseQueryOperatorsAndPseudoFields(t *testing.T) {
+	tests := []struct {
+		query string
+		want  Condition
+	}{
+		{"sys_class_nameINSTANCEOFtask", Condition{Field: "sys_class_name", Operator: InstanceOf, Value: "task"}},
+		{"numberMATCH_RGXINC[0-9]+", Condition{Field: "number", Operator: MatchesRegex, Value: "INC[0-9]+"}},
+		{"123TEXTQUERY321=email outage", Condition{Field: TextQueryField, Operator: Equals, Value: "email outage"}},
+		{"IR_AND_OR_QUERY=vpn", Condition{Field: "IR_AND_OR_QUERY", Operator: Equals, Value: "vpn"}},
+	}
+	for _, tt := range tests {
+		parsed, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tt.query, err)
+			continue
+		}
+		if got := parsed.Queries[0].Clauses[0].Any[0]; got != tt.want {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+		if got := parsed.String(); got != tt.query {
+			t.Errorf("String() = %q, want %q", got, tt.query)
+		}
+	}
+}
+
+func TestParseQueryRejectsMalformedClauses(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"active=true^prioritySOMETHING1", "unknown operator"},
+		{"=true", "no field name"},
+		{"active", "no operator"},
+		{"assigned_toISEMPTYx", "takes no value"},
+		{"Active=true", "no field name"},
+		{"caller_id..name=x", "bad field name"},
+	}
+	for _, tt := range tests {
+		_, err := ParseQuery(tt.query)
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseQuery(%q) error = %v, want QueryError containing %q", tt.query, err, tt.want)
+		}
+	}
+}
+
+// dictionaryServer serves sys_db_object and sys_dictionary rows for
+// incident, which extends task, and sys_user.
+func dictionaryServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	superClass := map[string]string{"incident": "task", "task": "", "sys_user": ""}
+	fields := map[string][]map[string]string{
+		"task": {
+			{"element": ""},
+			{"element": "active", "internal_type": "boolean"},
+			{"element": "sys_class_name", "internal_type": "sys_class_name"},
+			{"element": "opened_at", "internal_type": "glide_date_time"},
+			{"element": "assigned_to", "internal_type": "reference", "reference": "sys_user"},
+		},
+		"incident": {
+			{"element": "caller_id", "internal_type": "reference", "reference": "sys_user"},
+			{"element": "severity", "internal_type": "integer", "max_length": "40"},
+		},
+		"sys_user": {
+			{"element": "name", "internal_type": "string"},
+		},
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Query().Get("sysparm_query"), "name=")
+		var result []map[string]string
+		switch r.URL.Path {
+		case "/api/now/table/sys_db_object":
+			if super, ok := superClass[name]; ok {
+				result = append(result, map[string]string{"name": name, "super_class.name": super})
+			}
+		case "/api/now/table/sys_dictionary":
+			result = fields[name]
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestValidateQuery(t *testing.T) {
+	c := testClient(t, dictionaryServer(t))
+	ctx := context.Background()
+
+	if _, err := c.ValidateQuery(ctx, "incident", "active=true^caller_id.name=Abel^opened_atRELATIVEGT@hour@ago@2^ORDERBYseverity"); err != nil {
+		t.Errorf("valid query rejected: %v", err)
+	}
+
+	if _, err := c.ValidateQuery(ctx, "incident", "123TEXTQUERY321=vpn^IR_OR_QUERY=mail^sys_class_nameINSTANCEOFtask"); err != nil {
+		t.Errorf("keyword search rejected: %v", err)
+	}
+
+	_, err := c.ValidateQuery(ctx, "incident", "actve=true^severityONToday^caller_id.nmae=x^active.name=x")
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) {
+		t.Fatalf("error = %v, want QueryError", err)
+	}
+	want := []string{
+		`incident has no field "actve"`,
+		"ON needs a date field, severity is integer",
+		`incident has no field "caller_id.nmae"`,
+		`incident has no field "active.name"`,
+	}
+	if got := strings.Join(queryErr.Problems, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("problems:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	if _, err := c.ValidateQuery(ctx, "u_missing", "active=true"); !errors.As(err, &queryErr) {
+		t.Errorf("unknown table error = %v, want QueryError", err)
+	}
+}
@@ -0,0 +1,125 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Record is a Table API record keyed by field name.
+type Record = map[string]any
+
+// RecordOptions control how records are returned.
+type RecordOptions struct {
+	// Fields limits the returned fields; empty means all fields.
+	Fields []string
+	// DisplayValue is "true", "false" or "all", as for
+	// sysparm_display_value. Empty leaves the instance default.
+	DisplayValue string
+}
+
+// ListOptions select and shape the records returned by ListRecords.
+type ListOptions struct {
+	RecordOptions
+	// Query is an encoded query.
+	Query string
+	// Limit caps the number of records returned; zero means all records up
+	// to the client's MaxRecords.
+	Limit int
+}
+
+// ValidDisplayValue reports whether v is an accepted DisplayValue.
+func ValidDisplayValue(v string) bool {
+	switch v {
+	case "", "true", "false", "all":
+		return true
+	}
+	return false
+}
+
+func (o RecordOptions) params() url.Values {
+	params := url.Values{}
+	if len(o.Fields) > 0 {
+		params.Set("sysparm_fields", strings.Join(o.Fields, ","))
+	}
+	if o.DisplayValue != "" {
+		params.Set("sysparm_display_value", o.DisplayValue)
+	}
+	return params
+}
+
+// recordEndpoint returns the Table API path for a table or one of its
+// records, with the given query parameters.
+func recordEndpoint(table, sysID string, params url.Values) string {
+	endpoint := "/api/now/table/" + url.PathEscape(table)
+	if sysID != "" {
+		endpoint += "/" + url.PathEscape(sysID)
+	}
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	return endpoint
+}
+
+// decodeRecord parses a single-record Table API response.
+func decodeRecord(data []byte) (Record, error) {
+	var response struct {
+		Result Record `json:"result"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse record: %w", err)
+	}
+	return response.Result, nil
+}
+
+// ListRecords returns the records of table matching opts.
+func (c *Client) ListRecords(ctx context.Context, table string, opts ListOptions) ([]Record, error) {
+	params := opts.params()
+	if opts.Query != "" {
+		params.Set("sysparm_query", opts.Query)
+	}
+
+	records, err := collect[Record](c.NewPager(ctx, table, params).Limit(opts.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s records: %w", table, err)
+	}
+	return records, nil
+}
+
+// GetRecord returns the record of table with the given sys_id.
+func (c *Client) GetRecord(ctx context.Context, table, sysID string, opts RecordOptions) (Record, error) {
+	data, err := c.Request(ctx, "GET", recordEndpoint(table, sysID, opts.params()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s record %s: %w", table, sysID, err)
+	}
+	return decodeRecord(data)
+}
+
+// CreateRecord inserts a record into table and returns it as stored.
+func (c *Client) CreateRecord(ctx context.Context, table string, fields Record, opts RecordOptions) (Record, error) {
+	data, err := c.Request(ctx, "POST", recordEndpoint(table, "", opts.params()), fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s record: %w", table, err)
+	}
+	return decodeRecord(data)
+}
+
+// UpdateRecord changes the given fields of a record and returns it as
+// stored.
+func (c *Client) UpdateRecord(ctx context.Context, table, sysID string, fields Record, opts RecordOptions) (Record, error) {
+	data, err := c.Request(ctx, "PATCH", recordEndpoint(table, sysID, opts.params()), fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update %s record %s: %w", table, sysID, err)
+	}
+	return decodeRecord(data)
+}
+
+// DeleteRecord deletes the record of table with the given sys_id.
+func (c *Client) DeleteRecord(ctx context.Context, table, sysID string) error {
+	if _, err := c.Request(ctx, "DELETE", recordEndpoint(table, sysID, nil), nil); err != nil {
+		return fmt.Errorf("failed to delete %s record %s: %w", table, sysID, err)
+	}
+	return nil
+}
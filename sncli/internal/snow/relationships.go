@@ -0,0 +1,248 @@
+package snow
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// RelationshipKind is how one table is related to another.
+type RelationshipKind string
+
+const (
+	// Reference is a reference field pointing at one record.
+	Reference RelationshipKind = "reference"
+	// GlideList is a list field holding references to many records.
+	GlideList RelationshipKind = "glide_list"
+	// DocumentID is a polymorphic reference: the target table is stored
+	// in another field of the record, named by Via.
+	DocumentID RelationshipKind = "document_id"
+	// ManyToMany is a sys_m2m definition; Via is the link table.
+	ManyToMany RelationshipKind = "many_to_many"
+	// RelatedList is a sys_relationship related list shown on the source
+	// table's forms and listing target records.
+	RelatedList RelationshipKind = "related_list"
+	// Inheritance links a table to the table it extends.
+	Inheritance RelationshipKind = "inheritance"
+)
+
+// AnyTable is the target of a document_id relationship, which may point
+// at a record of any table.
+const AnyTable = "*"
+
+// inChunk is the number of table names put in one IN condition, which
+// keeps request URLs short.
+const inChunk = 50
+
+// RelationshipInfo represents a relationship between tables
+type RelationshipInfo struct {
+	SourceTable string           `json:"source_table"`
+	TargetTable string           `json:"target_table"`
+	Field       string           `json:"field,omitempty"`
+	Label       string           `json:"label,omitempty"`
+	Type        RelationshipKind `json:"type"`
+	// Via is the link table of a many-to-many relationship or the field
+	// holding the table name of a document_id reference.
+	Via           string `json:"via,omitempty"`
+	IsParentChild bool   `json:"is_parent_child"`
+	// Cardinality reads from source to target: N:1 means many source
+	// records point at one target record.
+	Cardinality string `json:"cardinality"`
+}
+
+// referenceField is a sys_dictionary row describing a reference, list or
+// document_id field
+type referenceField struct {
+	Name        string `json:"name"`
+	Field       string `json:"element"`
+	Label       string `json:"column_label"`
+	Type        string `json:"internal_type"`
+	Reference   string `json:"reference"`
+	Unique      string `json:"unique"`
+	DependentOn string `json:"dependent_on_field"`
+}
+
+// m2mDefinition is a sys_m2m row
+type m2mDefinition struct {
+	From  string `json:"from_table"`
+	To    string `json:"to_table"`
+	Table string `json:"m2m_table"`
+}
+
+// relatedList is a sys_relationship row. Scripted relationships leave the
+// basic tables empty.
+type relatedList struct {
+	Name      string `json:"name"`
+	AppliesTo string `json:"basic_apply_to"`
+	QueryFrom string `json:"basic_query_from"`
+}
+
+// GetRelationships retrieves all relationships of the given tables: their
+// outgoing and incoming reference, glide_list and document_id fields, the
+// tables they extend, and the many-to-many and related list definitions
+// involving them. Tables are looked up concurrently and in Batch API
+// chunks; the result follows the order of tables, with many-to-many and
+// related lists last.
+func (c *Client) GetRelationships(ctx context.Context, tables []Table) ([]RelationshipInfo, error) {
+	fields := "name,element,column_label,internal_type,reference,unique,dependent_on_field"
+	params := make([]url.Values, 0, 2*len(tables))
+	for _, table := range tables {
+		// Fields of this table pointing elsewhere
+		outgoing := url.Values{}
+		outgoing.Set("sysparm_query", NewQuery().
+			Where("internal_type", In, string(Reference), string(GlideList), string(DocumentID)).
+			Eq("name", table.Name).
+			String())
+		outgoing.Set("sysparm_fields", fields)
+		outgoing.Set("sysparm_exclude_reference_link", "true")
+
+		// Fields of other tables pointing at this one
+		incoming := url.Values{}
+		incoming.Set("sysparm_query", NewQuery().
+			Where("internal_type", In, string(Reference), string(GlideList)).
+			Eq("reference", table.Name).
+			String())
+		incoming.Set("sysparm_fields", fields)
+		incoming.Set("sysparm_exclude_reference_link", "true")
+
+		params = append(params, outgoing, incoming)
+	}
+	refs, err := collectEach[referenceField](ctx, c, "relationships", "sys_dictionary", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch relationships: %w", err)
+	}
+
+	var chunks [][]string
+	for lo := 0; lo < len(tables); lo += inChunk {
+		var names []string
+		for _, table := range tables[lo:min(lo+inChunk, len(tables))] {
+			names = append(names, table.Name)
+		}
+		chunks = append(chunks, names)
+	}
+	m2ms, err := collectEach[m2mDefinition](ctx, c, "many-to-many", "sys_m2m",
+		chunkParams(chunks, "from_table", "to_table", "from_table,to_table,m2m_table"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch many-to-many relationships: %w", err)
+	}
+	lists, err := collectEach[relatedList](ctx, c, "related lists", "sys_relationship",
+		chunkParams(chunks, "basic_apply_to", "basic_query_from", "name,basic_apply_to,basic_query_from"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch related lists: %w", err)
+	}
+
+	var relationships []RelationshipInfo
+	seen := map[RelationshipInfo]bool{}
+	add := func(rels ...RelationshipInfo) {
+		for _, rel := range rels {
+			if !seen[rel] {
+				seen[rel] = true
+				relationships = append(relationships, rel)
+			}
+		}
+	}
+	for i, table := range tables {
+		add(tableRelationships(table, refs[2*i], refs[2*i+1])...)
+	}
+	for _, defs := range m2ms {
+		for _, m := range defs {
+			add(RelationshipInfo{
+				SourceTable: m.From,
+				TargetTable: m.To,
+				Type:        ManyToMany,
+				Via:         m.Table,
+				Cardinality: "N:M",
+			})
+		}
+	}
+	for _, defs := range lists {
+		for _, l := range defs {
+			if l.AppliesTo == "" || l.QueryFrom == "" {
+				continue
+			}
+			add(RelationshipInfo{
+				SourceTable: l.AppliesTo,
+				TargetTable: l.QueryFrom,
+				Label:       l.Name,
+				Type:        RelatedList,
+				Cardinality: "1:N",
+			})
+		}
+	}
+	return relationships, nil
+}
+
+// chunkParams selects the records whose from or to field names one of the
+// tables of a chunk, one query per chunk.
+func chunkParams(chunks [][]string, from, to, fields string) []url.Values {
+	params := make([]url.Values, len(chunks))
+	for i, names := range chunks {
+		params[i] = url.Values{}
+		params[i].Set("sysparm_query", NewQuery().
+			Where(from, In, names...).
+			Or(to, In, names...).
+			String())
+		params[i].Set("sysparm_fields", fields)
+		params[i].Set("sysparm_exclude_reference_link", "true")
+	}
+	return params
+}
+
+// tableRelationships returns the relationships of table's own fields, of
+// the fields of other tables referencing it, and its parent-child link to
+// the table it extends.
+func tableRelationships(table Table, outgoing, incoming []referenceField) []RelationshipInfo {
+	var relationships []RelationshipInfo
+	for _, refs := range [][]referenceField{outgoing, incoming} {
+		for _, ref := range refs {
+			if rel, ok := fieldRelationship(ref); ok {
+				relationships = append(relationships, rel)
+			}
+		}
+	}
+
+	// Check for parent-child relationships
+	if table.SuperClass != "" {
+		relationships = append(relationships, RelationshipInfo{
+			SourceTable:   table.Name,
+			TargetTable:   table.SuperClass,
+			Field:         "super_class",
+			Type:          Inheritance,
+			IsParentChild: true,
+			Cardinality:   "1:1",
+		})
+	}
+
+	return relationships
+}
+
+// fieldRelationship describes the relationship a sys_dictionary field
+// creates. It reports false for fields that do not point at a table.
+func fieldRelationship(ref referenceField) (RelationshipInfo, bool) {
+	rel := RelationshipInfo{
+		SourceTable: ref.Name,
+		TargetTable: ref.Reference,
+		Field:       ref.Field,
+		Label:       ref.Label,
+		Type:        RelationshipKind(ref.Type),
+	}
+	switch rel.Type {
+	case Reference:
+		rel.Cardinality = "N:1"
+		if ref.Unique == "true" {
+			rel.Cardinality = "1:1"
+		}
+	case GlideList:
+		rel.Cardinality = "N:M"
+	case DocumentID:
+		rel.TargetTable = AnyTable
+		rel.Via = ref.DependentOn
+		rel.Cardinality = "N:1"
+	default:
+		return rel, false
+	}
+	if rel.SourceTable == "" || rel.TargetTable == "" || rel.Field == "" {
+		return rel, false
+	}
+	return rel, true
+}
@@ -0,0 +1,70 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetRelationshipsCoversEveryKind(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("sysparm_query")
+		var result []map[string]string
+		switch {
+		case query == "internal_typeINreference,glide_list,document_id^name=u_order":
+			result = []map[string]string{
+				{"name": "u_order", "element": "u_customer", "internal_type": "reference", "reference": "u_customer"},
+				{"name": "u_order", "element": "u_invoice", "internal_type": "reference", "reference": "u_invoice", "unique": "true"},
+				{"name": "u_order", "element": "u_watchers", "internal_type": "glide_list", "reference": "sys_user"},
+				{"name": "u_order", "element": "u_document", "internal_type": "document_id", "dependent_on_field": "u_table"},
+			}
+		case query == "internal_typeINreference,glide_list^reference=u_customer":
+			result = []map[string]string{
+				{"name": "u_order", "element": "u_customer", "internal_type": "reference", "reference": "u_customer"},
+			}
+		case strings.HasSuffix(r.URL.Path, "/sys_m2m"):
+			if query != "from_tableINu_order,u_customer^ORto_tableINu_order,u_customer" {
+				t.Errorf("sys_m2m query = %s", query)
+			}
+			result = []map[string]string{
+				{"from_table": "u_order", "to_table": "u_product", "m2m_table": "u_m2m_orders_products"},
+			}
+		case strings.HasSuffix(r.URL.Path, "/sys_relationship"):
+			result = []map[string]string{
+				{"name": "Open orders", "basic_apply_to": "u_customer", "basic_query_from": "u_order"},
+				{"name": "Scripted", "basic_apply_to": "", "basic_query_from": ""},
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	tables := []Table{{Name: "u_order", SuperClass: "task"}, {Name: "u_customer"}}
+	rels, err := c.GetRelationships(context.Background(), tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, rel := range rels {
+		got = append(got, fmt.Sprintf("%s %s.%s -> %s %s via %s (%s)",
+			rel.Type, rel.SourceTable, rel.Field, rel.TargetTable, rel.Cardinality, rel.Via, rel.Label))
+	}
+	want := []string{
+		"reference u_order.u_customer -> u_customer N:1 via  ()",
+		"reference u_order.u_invoice -> u_invoice 1:1 via  ()",
+		"glide_list u_order.u_watchers -> sys_user N:M via  ()",
+		"document_id u_order.u_document -> * N:1 via u_table ()",
+		"inheritance u_order.super_class -> task 1:1 via  ()",
+		"many_to_many u_order. -> u_product N:M via u_m2m_orders_products ()",
+		"related_list u_customer. -> u_order 1:N via  (Open orders)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("relationships:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
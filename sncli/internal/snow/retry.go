@@ -0,0 +1,120 @@
+package snow
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how Client.Request retries failed calls.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry; it doubles on every
+	// following retry.
+	BaseDelay time.Duration
+	// MaxDelay caps both the computed backoff and any server-requested wait.
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used by clients created with NewClient.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 4,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+}
+
+// DefaultTimeout bounds a single HTTP attempt.
+const DefaultTimeout = 60 * time.Second
+
+// retryable reports whether a response status is worth retrying. 429 and 503
+// are rejected before the instance does any work, so they are safe for every
+// method; gateway errors may have reached the instance and are only retried
+// for idempotent methods.
+func retryable(method string, status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent(method)
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// backoff returns the wait before retry number attempt (starting at 1),
+// using exponential backoff with full jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// serverDelay returns how long the instance asked us to wait, from
+// Retry-After (seconds or HTTP date) or ServiceNow's X-RateLimit-Reset
+// (epoch seconds). It returns zero when neither header is usable.
+func serverDelay(header http.Header, now time.Time) time.Duration {
+	if header == nil {
+		return 0
+	}
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return t.Sub(now)
+		}
+	}
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(reset, 0).Sub(now)
+		}
+	}
+	return 0
+}
+
+// wait sleeps for the delay before retry number attempt, preferring the
+// server's own hint over the computed backoff.
+func (p RetryPolicy) wait(ctx context.Context, attempt int, header http.Header) error {
+	d := serverDelay(header, time.Now())
+	if d <= 0 {
+		d = p.backoff(attempt)
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// shouldRetry decides whether the outcome of an attempt warrants another.
+func shouldRetry(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return retryable(method, authErr.Status)
+	}
+	// Transport errors: dropped connections, resets, per-attempt timeouts.
+	return idempotent(method)
+}
@@ -0,0 +1,70 @@
+package snow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestRetriesThrottledCalls(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"result":[]}`))
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	c.Retry = RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	if _, err := c.Request(context.Background(), "GET", "/api/now/table/sys_user", nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls, want 3", calls)
+	}
+}
+
+func TestRequestGivesUpAfterMaxRetries(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	c.Retry = RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+	_, err := c.Request(context.Background(), "POST", "/api/now/table/incident", map[string]string{})
+	var authErr *AuthError
+	if !errors.As(err, &authErr) || authErr.Status != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want 503 AuthError", err)
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls, want 3", calls)
+	}
+}
+
+func TestServerDelay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	h := http.Header{}
+	h.Set("X-RateLimit-Remaining", "0")
+	h.Set("X-RateLimit-Reset", "1700000005")
+	if d := serverDelay(h, now); d != 5*time.Second {
+		t.Errorf("rate-limit delay = %v, want 5s", d)
+	}
+
+	h.Set("Retry-After", "2")
+	if d := serverDelay(h, now); d != 2*time.Second {
+		t.Errorf("Retry-After delay = %v, want 2s", d)
+	}
+}
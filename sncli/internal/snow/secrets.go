@@ -0,0 +1,274 @@
+package snow
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseEnv holds the passphrase that unlocks the config secrets.
+const PassphraseEnv = "SNCLI_PASSPHRASE"
+
+// NewPassphraseEnv holds the new passphrase for a non-interactive rekey.
+const NewPassphraseEnv = "SNCLI_NEW_PASSPHRASE"
+
+// ErrNoPassphrase is returned when the config needs a passphrase and none
+// is available.
+var ErrNoPassphrase = errors.New("config secrets are encrypted - set " + PassphraseEnv + " or run interactively")
+
+// ErrBadPassphrase is returned when the passphrase does not unlock the
+// config.
+var ErrBadPassphrase = errors.New("incorrect passphrase for config secrets")
+
+// PassphraseFunc obtains the passphrase for the config secrets. confirm is
+// set when a new passphrase is being chosen. The default reads
+// $SNCLI_PASSPHRASE; the CLI replaces it with a terminal prompt.
+var PassphraseFunc = func(confirm bool) (string, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return p, nil
+	}
+	return "", ErrNoPassphrase
+}
+
+// WarnFunc shows a warning about the config file, such as secrets left in
+// plaintext. The default writes it to stderr.
+var WarnFunc = func(msg string) {
+	fmt.Fprintln(os.Stderr, "Warning: "+msg)
+}
+
+// scrypt parameters for new keys, as recommended for interactive logins.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// checkPlaintext is sealed into the encryption header so a wrong passphrase
+// is detected before any profile is touched.
+const checkPlaintext = "sncli"
+
+// Encryption describes how the secrets in the config file are sealed: an
+// AES-256-GCM key derived from a passphrase with scrypt.
+type Encryption struct {
+	KDF   string `json:"kdf"`
+	Salt  []byte `json:"salt"`
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	Check string `json:"check"`
+}
+
+// secrets are the Config fields that are never written in plaintext once
+// encryption is enabled.
+type secrets struct {
+	Password     string `json:"password,omitempty"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	Token        *Token `json:"token,omitempty"`
+}
+
+func (c *Config) secrets() secrets {
+	return secrets{Password: c.Password, ClientSecret: c.ClientSecret, Token: c.Token}
+}
+
+func (c *Config) hasSecrets() bool {
+	return c.Password != "" || c.ClientSecret != "" || c.Token != nil
+}
+
+// unlockedKeys caches derived keys by salt so a passphrase is asked for at
+// most once per process.
+var unlockedKeys = map[string][]byte{}
+
+// newEncryption derives a key for a fresh passphrase and returns the header
+// describing it.
+func newEncryption(passphrase string) (*Encryption, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+	enc := &Encryption{KDF: "scrypt", Salt: salt, N: scryptN, R: scryptR, P: scryptP}
+	key, err := enc.derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	check, err := seal(key, []byte(checkPlaintext))
+	if err != nil {
+		return nil, err
+	}
+	enc.Check = check
+	unlockedKeys[string(salt)] = key
+	return enc, nil
+}
+
+func (e *Encryption) derive(passphrase string) ([]byte, error) {
+	if e.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation %q", e.KDF)
+	}
+	key, err := scrypt.Key([]byte(passphrase), e.Salt, e.N, e.R, e.P, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	return key, nil
+}
+
+// key returns the unlocked key, asking for the passphrase if needed.
+func (e *Encryption) key() ([]byte, error) {
+	if key, ok := unlockedKeys[string(e.Salt)]; ok {
+		return key, nil
+	}
+	passphrase, err := PassphraseFunc(false)
+	if err != nil {
+		return nil, err
+	}
+	key, err := e.derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	check, err := open(key, e.Check)
+	if err != nil || !bytes.Equal(check, []byte(checkPlaintext)) {
+		return nil, ErrBadPassphrase
+	}
+	unlockedKeys[string(e.Salt)] = key
+	return key, nil
+}
+
+// seal encrypts plaintext with AES-GCM and returns base64(nonce||ciphertext).
+func seal(key, plaintext []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// open reverses seal.
+func open(key []byte, sealed string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secrets: %v", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed secrets are truncated")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets: %v", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// Open decrypts a profile's sealed secrets into its plaintext fields.
+// Profiles without sealed secrets are left alone.
+func (f *ConfigFile) Open(config *Config) error {
+	if config.Sealed == "" {
+		return nil
+	}
+	if f.Encryption == nil {
+		return fmt.Errorf("profile %q has sealed secrets but the config has no encryption header", config.Profile)
+	}
+	key, err := f.Encryption.key()
+	if err != nil {
+		return err
+	}
+	plaintext, err := open(key, config.Sealed)
+	if err != nil {
+		return err
+	}
+	var s secrets
+	if err := json.Unmarshal(plaintext, &s); err != nil {
+		return fmt.Errorf("failed to parse secrets: %v", err)
+	}
+	config.Password, config.ClientSecret, config.Token = s.Password, s.ClientSecret, s.Token
+	config.Sealed = ""
+	return nil
+}
+
+// EnableEncryption turns on encryption for a file that stores plaintext
+// secrets, asking for a new passphrase.
+func (f *ConfigFile) EnableEncryption() error {
+	if f.Encryption != nil {
+		return nil
+	}
+	passphrase, err := PassphraseFunc(true)
+	if err != nil {
+		return err
+	}
+	enc, err := newEncryption(passphrase)
+	if err != nil {
+		return err
+	}
+	f.Encryption = enc
+	return nil
+}
+
+// Rekey opens every profile with the current passphrase and re-encrypts
+// them under newPassphrase. A plaintext file is encrypted for the first time.
+func (f *ConfigFile) Rekey(newPassphrase string) error {
+	if newPassphrase == "" {
+		return fmt.Errorf("passphrase must not be empty")
+	}
+	for name, config := range f.Profiles {
+		config.Profile = name
+		if err := f.Open(config); err != nil {
+			return err
+		}
+	}
+	enc, err := newEncryption(newPassphrase)
+	if err != nil {
+		return err
+	}
+	f.Encryption = enc
+	return f.Save()
+}
+
+// sealed returns copies of the profiles with their secrets encrypted, ready
+// to be written. In-memory configs keep their plaintext.
+func (f *ConfigFile) sealed() (map[string]*Config, error) {
+	if f.Encryption == nil {
+		return f.Profiles, nil
+	}
+	out := make(map[string]*Config, len(f.Profiles))
+	for name, config := range f.Profiles {
+		c := *config
+		if c.hasSecrets() {
+			key, err := f.Encryption.key()
+			if err != nil {
+				return nil, err
+			}
+			plaintext, err := json.Marshal(c.secrets())
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal secrets: %v", err)
+			}
+			if c.Sealed, err = seal(key, plaintext); err != nil {
+				return nil, err
+			}
+			c.Password, c.ClientSecret, c.Token = "", "", nil
+		}
+		out[name] = &c
+	}
+	return out, nil
+}
@@ -0,0 +1,43 @@
+package snow
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretsEncryptedAtRest(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ProfileEnv, "")
+	t.Setenv(PassphraseEnv, "correct horse")
+	unlockedKeys = map[string][]byte{}
+
+	if err := SaveConfig(&Config{Instance: "dev123", Username: "admin", Password: "hunter2"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".sncli", "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Fatalf("password stored in plaintext:\n%s", data)
+	}
+
+	unlockedKeys = map[string][]byte{}
+	cfg, err := ReadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Password != "hunter2" {
+		t.Errorf("password = %q, want hunter2", cfg.Password)
+	}
+
+	unlockedKeys = map[string][]byte{}
+	t.Setenv(PassphraseEnv, "wrong")
+	if _, err := ReadConfig(""); !errors.Is(err, ErrBadPassphrase) {
+		t.Errorf("err = %v, want ErrBadPassphrase", err)
+	}
+}
@@ -0,0 +1,213 @@
+package snow
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TableSelection picks the tables of a schema. Its criteria combine: a
+// table is selected when it is in one of Scopes (or any scope with
+// AllScopes, or when no scope is given), matches one of Tables if any are
+// given, and extends Extends if set.
+type TableSelection struct {
+	Scopes    []string `json:"scopes,omitempty"`
+	AllScopes bool     `json:"all_scopes,omitempty"`
+	// Tables are glob patterns matching whole table names, or regular
+	// expressions between slashes such as /^x_app_(order|item)$/.
+	Tables []string `json:"tables,omitempty"`
+	// Extends selects the tables extending this one, directly or not. The
+	// table itself is not selected.
+	Extends string `json:"extends,omitempty"`
+}
+
+// Empty reports whether sel has no criteria, which would select every
+// table of the instance.
+func (sel TableSelection) Empty() bool {
+	return len(sel.Scopes) == 0 && !sel.AllScopes && len(sel.Tables) == 0 && sel.Extends == ""
+}
+
+// String describes sel, for example "x_app, x_crm extending task".
+func (sel TableSelection) String() string {
+	var parts []string
+	switch {
+	case len(sel.Scopes) > 0:
+		parts = append(parts, strings.Join(sel.Scopes, ", "))
+	case sel.AllScopes:
+		parts = append(parts, "all scopes")
+	}
+	if len(sel.Tables) > 0 {
+		parts = append(parts, "tables "+strings.Join(sel.Tables, ", "))
+	}
+	if sel.Extends != "" {
+		parts = append(parts, "extending "+sel.Extends)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Validate checks that the table patterns of sel compile.
+func (sel TableSelection) Validate() error {
+	_, err := sel.matcher()
+	return err
+}
+
+// matcher returns a function reporting whether a table name matches one
+// of the table patterns, or every name when there are none.
+func (sel TableSelection) matcher() (func(string) bool, error) {
+	if len(sel.Tables) == 0 {
+		return func(string) bool { return true }, nil
+	}
+	var matches []func(string) bool
+	for _, pattern := range sel.Tables {
+		if expr, ok := regexPattern(pattern); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid table pattern %s: %w", pattern, err)
+			}
+			matches = append(matches, re.MatchString)
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid table pattern %s: %w", pattern, err)
+		}
+		matches = append(matches, func(name string) bool {
+			ok, _ := path.Match(pattern, name)
+			return ok
+		})
+	}
+	return func(name string) bool {
+		for _, match := range matches {
+			if match(name) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// regexPattern returns the expression of a pattern written between
+// slashes.
+func regexPattern(pattern string) (string, bool) {
+	if len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		return pattern[1 : len(pattern)-1], true
+	}
+	return "", false
+}
+
+// prefixes returns the literal prefixes of the table patterns, which the
+// instance can filter on, or nil when a pattern has none.
+func (sel TableSelection) prefixes() []string {
+	var prefixes []string
+	for _, pattern := range sel.Tables {
+		if _, ok := regexPattern(pattern); ok {
+			return nil
+		}
+		prefix := pattern[:strings.IndexAny(pattern+"*", `*?[\`)]
+		if prefix == "" {
+			return nil
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+// query returns the sys_db_object query narrowing the tables down to the
+// scopes and patterns of sel. The patterns are matched again exactly.
+func (sel TableSelection) query() *Query {
+	q := NewQuery()
+	switch {
+	case sel.AllScopes:
+	case len(sel.Scopes) == 1:
+		q.Eq("sys_scope.scope", sel.Scopes[0])
+	case len(sel.Scopes) > 1:
+		q.Where("sys_scope.scope", In, sel.Scopes...)
+	}
+	for i, prefix := range sel.prefixes() {
+		if i == 0 {
+			q.Where("name", StartsWith, prefix)
+		} else {
+			q.Or("name", StartsWith, prefix)
+		}
+	}
+	return q
+}
+
+// SelectTables retrieves the tables picked by sel with their hierarchy,
+// and their fields when detailed is set.
+func (c *Client) SelectTables(ctx context.Context, sel TableSelection, detailed bool) ([]Table, error) {
+	match, err := sel.matcher()
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []dbObject
+	if sel.Extends != "" {
+		// Descendants may extend tables of other scopes, so the whole tree
+		// is walked before filtering on scope
+		if objects, err = c.descendants(ctx, sel.Extends); err != nil {
+			return nil, err
+		}
+	} else {
+		params := url.Values{}
+		params.Set("sysparm_query", sel.query().String())
+		params.Set("sysparm_fields", tableColumns)
+		params.Set("sysparm_exclude_reference_link", "true")
+		if objects, err = collectCached[dbObject](ctx, c, "sys_db_object", params); err != nil {
+			return nil, fmt.Errorf("failed to fetch tables: %w", err)
+		}
+	}
+
+	tables := make([]Table, 0, len(objects))
+	for _, o := range objects {
+		if len(sel.Scopes) > 0 && !slices.Contains(sel.Scopes, o.Scope) {
+			continue
+		}
+		if match(o.Name) {
+			tables = append(tables, o.table())
+		}
+	}
+
+	if err := c.resolveInheritance(ctx, tables, detailed); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// descendants returns the tables extending base, one generation at a
+// time, each generation in name order.
+func (c *Client) descendants(ctx context.Context, base string) ([]dbObject, error) {
+	var objects []dbObject
+	seen := map[string]bool{base: true}
+	for parents := []string{base}; len(parents) > 0; {
+		params := make([]url.Values, 0, len(parents))
+		for lo := 0; lo < len(parents); lo += inChunk {
+			p := url.Values{}
+			p.Set("sysparm_query", NewQuery().
+				Where("super_class.name", In, parents[lo:min(lo+inChunk, len(parents))]...).
+				OrderBy("name").
+				String())
+			p.Set("sysparm_fields", tableColumns)
+			p.Set("sysparm_exclude_reference_link", "true")
+			params = append(params, p)
+		}
+		found, err := collectEach[dbObject](ctx, c, "descendants", "sys_db_object", params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tables extending %s: %w", base, err)
+		}
+		parents = nil
+		for _, chunk := range found {
+			for _, o := range chunk {
+				if !seen[o.Name] {
+					seen[o.Name] = true
+					objects = append(objects, o)
+					parents = append(parents, o.Name)
+				}
+			}
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,79 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTableSelectionQuery(t *testing.T) {
+	tests := []struct {
+		sel  TableSelection
+		want string
+	}{
+		{TableSelection{Scopes: []string{"global"}}, "sys_scope.scope=global"},
+		{TableSelection{Scopes: []string{"x_app", "x_crm"}, Tables: []string{"x_app_*", "x_crm_order"}},
+			"sys_scope.scopeINx_app,x_crm^nameSTARTSWITHx_app_^ORnameSTARTSWITHx_crm_order"},
+		// A pattern without a literal prefix leaves names to the matcher
+		{TableSelection{AllScopes: true, Tables: []string{"x_app_*", "*_m2m_*"}}, ""},
+		{TableSelection{Tables: []string{"/^u_(order|item)$/"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.sel.query().String(); got != tt.want {
+			t.Errorf("query(%s) = %s, want %s", tt.sel, got, tt.want)
+		}
+	}
+	if err := (TableSelection{Tables: []string{"/(/"}}).Validate(); err == nil {
+		t.Error("invalid regular expression accepted")
+	}
+}
+
+func TestSelectTablesExtending(t *testing.T) {
+	children := map[string][]map[string]string{
+		"super_class.nameINtask^ORDERBYname": {
+			{"name": "incident", "sys_scope.scope": "global", "super_class.name": "task"},
+			{"name": "x_app_request", "sys_scope.scope": "x_app", "super_class.name": "task"},
+		},
+		"super_class.nameINincident,x_app_request^ORDERBYname": {
+			{"name": "x_app_major", "sys_scope.scope": "x_app", "super_class.name": "incident"},
+			{"name": "x_app_other", "sys_scope.scope": "x_app", "super_class.name": "x_app_request"},
+		},
+	}
+	ancestors := map[string]map[string]string{
+		"task":     {"name": "task", "sys_scope.scope": "global"},
+		"incident": children["super_class.nameINtask^ORDERBYname"][0],
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("sysparm_query")
+		var result []map[string]string
+		switch {
+		case strings.HasPrefix(query, "super_class.name"):
+			result = children[query]
+		case strings.HasPrefix(query, "nameIN"):
+			for _, name := range strings.Split(strings.TrimPrefix(query, "nameIN"), ",") {
+				result = append(result, ancestors[name])
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	sel := TableSelection{Scopes: []string{"x_app"}, Tables: []string{"/major|request/"}, Extends: "task"}
+	tables, err := c.SelectTables(context.Background(), sel, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, table := range tables {
+		got = append(got, table.HierarchyPath())
+	}
+	// x_app_major is found through incident, a table of another scope
+	want := "x_app_request -> task -> (base),x_app_major -> incident -> task -> (base)"
+	if strings.Join(got, ",") != want {
+		t.Errorf("tables = %s, want %s", strings.Join(got, ","), want)
+	}
+}
@@ -0,0 +1,124 @@
+package snow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const statsEndpoint = "/api/now/stats/"
+
+// statsQuery is one Aggregate API request.
+type statsQuery struct {
+	table  string
+	params url.Values
+}
+
+func (q statsQuery) endpoint() string {
+	return statsEndpoint + url.PathEscape(q.table) + "?" + q.params.Encode()
+}
+
+// statsResult is an Aggregate API answer, one per group when the request
+// groups records by a field.
+type statsResult struct {
+	Stats struct {
+		Count string            `json:"count"`
+		Min   map[string]string `json:"min"`
+		Max   map[string]string `json:"max"`
+	} `json:"stats"`
+	GroupBy []struct {
+		Field string `json:"field"`
+		Value string `json:"value"`
+	} `json:"groupby_fields"`
+}
+
+func (s statsResult) count() int {
+	n, _ := strconv.Atoi(s.Stats.Count)
+	return n
+}
+
+// decodeStats parses an Aggregate API response, whose result is an object
+// or, for grouped requests, an array of them.
+func decodeStats(body []byte) ([]statsResult, error) {
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse statistics: %w", err)
+	}
+	raw := bytes.TrimSpace(resp.Result)
+	if len(raw) > 0 && raw[0] == '[' {
+		var groups []statsResult
+		if err := json.Unmarshal(raw, &groups); err != nil {
+			return nil, fmt.Errorf("failed to parse statistics: %w", err)
+		}
+		return groups, nil
+	}
+	var one statsResult
+	if err := json.Unmarshal(raw, &one); err != nil {
+		return nil, fmt.Errorf("failed to parse statistics: %w", err)
+	}
+	return []statsResult{one}, nil
+}
+
+// aggregateEach runs queries on the Aggregate API and returns their results
+// in the same order. Like collectEach it bundles them into Batch API calls
+// of c.BatchSize sub-requests, run c.Concurrency at a time, and falls back
+// to individual requests without the Batch API. Statistics are not cached.
+func (c *Client) aggregateEach(ctx context.Context, task string, queries []statsQuery) ([][]statsResult, error) {
+	results := make([][]statsResult, len(queries))
+	single := func(ctx context.Context, i int) error {
+		body, err := c.Request(ctx, "GET", queries[i].endpoint(), nil)
+		if err != nil {
+			return fmt.Errorf("failed to fetch statistics of %s: %w", queries[i].table, err)
+		}
+		results[i], err = decodeStats(body)
+		return err
+	}
+
+	size := 1
+	if c.batching() {
+		size = c.batchSize()
+	}
+	err := c.forEachChunk(ctx, task, len(queries), size, func(ctx context.Context, lo, hi int) error {
+		var responses []*BatchResponse
+		if hi-lo > 1 && c.batching() {
+			reqs := make([]BatchRequest, 0, hi-lo)
+			for i := lo; i < hi; i++ {
+				reqs = append(reqs, BatchRequest{Method: "GET", URL: queries[i].endpoint()})
+			}
+			var err error
+			responses, err = c.Batch(ctx, reqs)
+			if err != nil && !errors.Is(err, ErrBatchUnsupported) {
+				return err
+			}
+		}
+
+		for i := lo; i < hi; i++ {
+			var resp *BatchResponse
+			if responses != nil {
+				resp = responses[i-lo]
+			}
+			if resp == nil {
+				if err := single(ctx, i); err != nil {
+					return err
+				}
+				continue
+			}
+			if resp.Status < 200 || resp.Status >= 300 {
+				return &AuthError{Message: string(resp.Body), Status: resp.Status}
+			}
+			stats, err := decodeStats(resp.Body)
+			if err != nil {
+				return err
+			}
+			results[i] = stats
+		}
+		return nil
+	})
+	return results, err
+}
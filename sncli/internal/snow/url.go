@@ -0,0 +1,69 @@
+package snow
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// NormalizeInstanceURL turns what a user types for an instance into a base
+// URL for the client:
+//
+//	dev12345                 -> https://dev12345.service-now.com
+//	itsm.example.com         -> https://itsm.example.com
+//	http://localhost:8080/   -> http://localhost:8080
+//
+// Only http and https are accepted, the port must be numeric and in range,
+// and user info, queries and fragments are rejected. A path is kept so the
+// instance can sit behind a proxy prefix.
+func NormalizeInstanceURL(instance string) (string, error) {
+	s := strings.TrimSpace(instance)
+	if s == "" {
+		return "", fmt.Errorf("instance is required")
+	}
+	if !strings.Contains(s, "://") {
+		host := s
+		if i := strings.IndexAny(host, ":/"); i >= 0 {
+			host = host[:i]
+		}
+		if !strings.Contains(host, ".") && host != "localhost" {
+			s = host + ".service-now.com" + s[len(host):]
+		}
+		s = "https://" + s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid instance URL %q: %w", instance, err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid instance URL %q: scheme must be http or https", instance)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid instance URL %q: must not contain credentials, a query or a fragment", instance)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return "", fmt.Errorf("invalid instance URL %q: missing host", instance)
+	}
+	if port := u.Port(); port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("invalid instance URL %q: bad port %q", instance, port)
+		}
+		host = net.JoinHostPort(host, port)
+	} else if strings.HasSuffix(u.Host, ":") {
+		return "", fmt.Errorf("invalid instance URL %q: empty port", instance)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	u.Host = host
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+
+	return u.String(), nil
+}
@@ -0,0 +1,34 @@
+package snow
+
+import "testing"
+
+func TestNormalizeInstanceURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"dev12345", "https://dev12345.service-now.com"},
+		{"dev12345.service-now.com", "https://dev12345.service-now.com"},
+		{"ITSM.Example.com/", "https://itsm.example.com"},
+		{"itsm.example.com:8443", "https://itsm.example.com:8443"},
+		{"http://localhost:8080", "http://localhost:8080"},
+		{"localhost:8080", "https://localhost:8080"},
+		{"https://proxy.example.com/snow/", "https://proxy.example.com/snow"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeInstanceURL(tt.in)
+		if err != nil {
+			t.Errorf("NormalizeInstanceURL(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeInstanceURL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "ftp://example.com", "https://example.com:99999", "https://user:pw@example.com", "https://example.com/?q=1", "https://:8080"} {
+		if got, err := NormalizeInstanceURL(in); err == nil {
+			t.Errorf("NormalizeInstanceURL(%q) = %q, want error", in, got)
+		}
+	}
+}
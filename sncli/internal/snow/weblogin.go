@@ -0,0 +1,171 @@
+package snow
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// authorizeEndpoint is the ServiceNow OAuth 2.0 authorization endpoint.
+const authorizeEndpoint = "/oauth_auth.do"
+
+// callbackPath is where the loopback listener receives the redirect.
+const callbackPath = "/callback"
+
+// DefaultWebLoginTimeout bounds how long WebLogin waits for the user to
+// finish signing in.
+const DefaultWebLoginTimeout = 5 * time.Minute
+
+// WebLoginOptions configures the browser-based authorization code flow.
+type WebLoginOptions struct {
+	// Port is the loopback port for the redirect listener. It must match the
+	// redirect URL registered for the OAuth application; zero picks a free
+	// port, which only works with instances that accept any loopback port.
+	Port int
+	// OpenBrowser is called with the authorization URL. The URL is also
+	// passed to it when the browser cannot be opened so it can be printed.
+	OpenBrowser func(authURL string) error
+	// Timeout bounds the wait for the redirect. Zero means
+	// DefaultWebLoginTimeout.
+	Timeout time.Duration
+}
+
+// callbackResult is what the loopback handler hands back to WebLogin.
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// WebLogin signs the user in through the browser with the OAuth 2.0
+// authorization code grant and PKCE. It listens on 127.0.0.1 for the
+// redirect, exchanges the code for a token and stores it on the client.
+func (c *Client) WebLogin(ctx context.Context, opts WebLoginOptions) error {
+	if c.Auth != AuthOAuth {
+		return fmt.Errorf("web login requires an OAuth client")
+	}
+	if opts.OpenBrowser == nil {
+		return fmt.Errorf("web login requires a way to open the browser")
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultWebLoginTimeout
+	}
+
+	verifier, err := randomString(32)
+	if err != nil {
+		return err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(opts.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to start redirect listener: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s%s", listener.Addr().String(), callbackPath)
+
+	results := make(chan callbackResult, 1)
+	server := &http.Server{
+		Handler:           callbackHandler(state, results),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if err := opts.OpenBrowser(c.authorizationURL(redirectURI, state, verifier)); err != nil {
+		return fmt.Errorf("failed to open browser: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for browser sign-in: %w", ctx.Err())
+	}
+	if result.err != nil {
+		return result.err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", result.code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("code_verifier", verifier)
+	return c.requestToken(ctx, form)
+}
+
+// authorizationURL builds the URL the user opens to sign in.
+func (c *Client) authorizationURL(redirectURI, state, verifier string) string {
+	challenge := sha256.Sum256([]byte(verifier))
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", c.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	return c.BaseURL + authorizeEndpoint + "?" + q.Encode()
+}
+
+// callbackHandler receives the authorization redirect, checks the state and
+// reports the code or the error once.
+func callbackHandler(state string, results chan<- callbackResult) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+
+		var result callbackResult
+		switch {
+		case q.Get("state") != state:
+			result.err = errors.New("authorization response has an unexpected state")
+		case q.Get("error") != "":
+			msg := q.Get("error")
+			if desc := q.Get("error_description"); desc != "" {
+				msg += ": " + desc
+			}
+			result.err = &AuthError{Message: msg, Status: http.StatusUnauthorized}
+		case q.Get("code") == "":
+			result.err = errors.New("authorization response has no code")
+		default:
+			result.code = q.Get("code")
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if result.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "<html><body><h3>Sign-in failed.</h3><p>Return to the terminal for details.</p></body></html>")
+		} else {
+			fmt.Fprint(w, "<html><body><h3>Signed in to sncli.</h3><p>You can close this window.</p></body></html>")
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+	})
+	return mux
+}
+
+// randomString returns n random bytes encoded as unpadded base64url, which is
+// a valid PKCE verifier for n >= 32.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,83 @@
+package snow
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// authServer is a stand-in authorization server. It approves every
+// authorization request and only issues a token when the PKCE verifier
+// matches the challenge it was given.
+func authServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var challenge string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case authorizeEndpoint:
+			q := r.URL.Query()
+			if q.Get("code_challenge_method") != "S256" {
+				t.Errorf("code_challenge_method = %q", q.Get("code_challenge_method"))
+			}
+			challenge = q.Get("code_challenge")
+			redirect, _ := url.Parse(q.Get("redirect_uri"))
+			rq := redirect.Query()
+			rq.Set("code", "auth-code")
+			rq.Set("state", q.Get("state"))
+			redirect.RawQuery = rq.Encode()
+			http.Redirect(w, r, redirect.String(), http.StatusFound)
+
+		case tokenEndpoint:
+			r.ParseForm()
+			sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if r.PostForm.Get("grant_type") != "authorization_code" ||
+				r.PostForm.Get("code") != "auth-code" ||
+				base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(tokenResponse{Error: "invalid_grant"})
+				return
+			}
+			json.NewEncoder(w).Encode(tokenResponse{
+				AccessToken:  "access",
+				RefreshToken: "refresh",
+				ExpiresIn:    1800,
+			})
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWebLogin(t *testing.T) {
+	srv := authServer(t)
+	c, err := NewOAuthClient(srv.URL, "cli", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The "browser" simply follows the authorization redirect back to the
+	// loopback listener.
+	browse := func(authURL string) error {
+		go func() {
+			resp, err := http.Get(authURL)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+		return nil
+	}
+
+	err = c.WebLogin(context.Background(), WebLoginOptions{OpenBrowser: browse, Timeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Token == nil || c.Token.AccessToken != "access" || c.Token.RefreshToken != "refresh" {
+		t.Errorf("token = %+v", c.Token)
+	}
+}
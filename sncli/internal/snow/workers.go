@@ -0,0 +1,72 @@
+package snow
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultConcurrency is the number of per-table metadata requests a Client
+// runs at once when Concurrency is not set.
+const DefaultConcurrency = 8
+
+// ProgressFunc is told how many of total items of a task are done. It is
+// called from one goroutine at a time.
+type ProgressFunc func(task string, done, total int)
+
+func (c *Client) concurrency() int {
+	if c.Concurrency > 0 {
+		return c.Concurrency
+	}
+	return DefaultConcurrency
+}
+
+// forEachChunk calls fn for chunks of up to size of the indexes 0..n-1 on
+// at most c.concurrency() goroutines: fn handles lo..hi-1 and progress
+// under task counts the indexes, not the chunks. The first error cancels
+// the context passed to the remaining calls and is returned. Callers store
+// results by index so output order does not depend on scheduling.
+func (c *Client) forEachChunk(ctx context.Context, task string, n, size int, fn func(ctx context.Context, lo, hi int) error) error {
+	chunks := (n + size - 1) / size
+	return c.forEachJob(ctx, task, n, chunks, func(ctx context.Context, j int) (int, error) {
+		lo, hi := j*size, min((j+1)*size, n)
+		return hi - lo, fn(ctx, lo, hi)
+	})
+}
+
+// forEachJob runs fn for jobs 0..jobs-1 on at most c.concurrency()
+// goroutines. Each job reports how many of the task's total items it
+// completed.
+func (c *Client) forEachJob(ctx context.Context, task string, total, jobs int, fn func(ctx context.Context, j int) (int, error)) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.concurrency())
+
+	var mu sync.Mutex
+	done := 0
+	report := func(finished int) {
+		if c.Progress == nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		done += finished
+		c.Progress(task, done, total)
+	}
+	report(0)
+
+	for j := 0; j < jobs; j++ {
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			finished, err := fn(ctx, j)
+			if err != nil {
+				return err
+			}
+			report(finished)
+			return nil
+		})
+	}
+	return g.Wait()
+}
@@ -0,0 +1,105 @@
+package snow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetRelationshipsKeepsTableOrder(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		// Later tables answer first
+		target := r.URL.Query().Get("sysparm_query")
+		target = target[strings.LastIndex(target, "=")+1:]
+		var i int
+		fmt.Sscanf(target, "u_table_%d", &i)
+		time.Sleep(time.Duration(10-i) * 5 * time.Millisecond)
+
+		result := []map[string]string{}
+		if strings.Contains(r.URL.Query().Get("sysparm_query"), "^reference=") {
+			result = append(result, map[string]string{
+				"name": "u_source", "element": "ref_" + target, "internal_type": "reference", "reference": target,
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": result})
+	}))
+	defer srv.Close()
+
+	c := testClient(t, srv)
+	c.Concurrency = 4
+	c.BatchSize = 1
+	var last int
+	c.Progress = func(task string, done, total int) {
+		if task != "relationships" {
+			return
+		}
+		if done < last || total != 20 {
+			t.Errorf("progress %s %d/%d after %d", task, done, total, last)
+		}
+		last = done
+	}
+
+	tables := make([]Table, 10)
+	for i := range tables {
+		tables[i] = Table{Name: fmt.Sprintf("u_table_%d", i)}
+	}
+	rels, err := c.GetRelationships(context.Background(), tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rel := range rels {
+		if want := fmt.Sprintf("u_table_%d", i); rel.TargetTable != want {
+			t.Errorf("relationship %d targets %s, want %s", i, rel.TargetTable, want)
+		}
+	}
+	if peak > 4 {
+		t.Errorf("%d requests in flight, want at most 4", peak)
+	}
+	if last != 20 {
+		t.Errorf("progress ended at %d, want 20", last)
+	}
+}
+
+func TestForEachChunkCancelsOnFirstError(t *testing.T) {
+	c := &Client{Concurrency: 2}
+	boom := errors.New("boom")
+	var started int32
+	err := c.forEachChunk(context.Background(), "test", 250, 5, func(ctx context.Context, lo, hi int) error {
+		atomic.AddInt32(&started, 1)
+		if lo == 0 {
+			return boom
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("error = %v, want %v", err, boom)
+	}
+	if started > 3 {
+		t.Errorf("%d calls started after the first error, want the rest skipped", started)
+	}
+}
@@ -1,270 +1,316 @@
-	package tui
-
-	import (
-	        "strings"
-
-	        tea "github.com/charmbracelet/bubbletea"
-	        "github.com/charmbracelet/lipgloss"
-	)
-
-	var (
-	        appWidth = 60
-	        
-	        subtleBlue   = lipgloss.Color("69")
-	        subtleGray   = lipgloss.Color("241")
-	        primaryBlue  = lipgloss.Color("39")
-	        
-	        focusedStyle = lipgloss.NewStyle().
-	                Background(lipgloss.Color("236")).
-	                Foreground(lipgloss.Color("252")).
-	                Padding(1, 2).
-	                MarginLeft(2).
-	                Width(appWidth - 4)
-	        
-	        blurredStyle = lipgloss.NewStyle().
-	                Foreground(lipgloss.Color("242")).
-	                Padding(1, 2).
-	                MarginLeft(2).
-	                Width(appWidth - 4)
-	        
-	        focusIndicator = lipgloss.NewStyle().
-	                Foreground(primaryBlue).
-	                SetString("▎")
-	        
-	        titleStyle = lipgloss.NewStyle().
-	                Foreground(subtleBlue).
-	                Bold(true).
-	                Padding(1, 2).
-	                MarginTop(1).
-	                MarginBottom(2).
-	                Width(appWidth).
-	                Align(lipgloss.Center)
-	        
-	        labelStyle = lipgloss.NewStyle().
-	                Foreground(subtleGray).
-	                PaddingLeft(2).
-	                MarginBottom(1)
-	        
-	        helpStyle = lipgloss.NewStyle().
-	                Foreground(subtleGray).
-	                Width(appWidth).
-	                Align(lipgloss.Center).
-	                MarginTop(2)
-	        
-	        footerStyle = lipgloss.NewStyle().
-	                Foreground(subtleGray).
-	                Width(appWidth).
-	                Align(lipgloss.Center).
-	                PaddingTop(2).
-	                BorderTop(true).
-	                BorderStyle(lipgloss.Border{
-	                        Top: "─",
-	                })
-	        
-	        docStyle = lipgloss.NewStyle().
-	                Padding(2).
-	                Align(lipgloss.Center)
-	)
-
-	type LoginModel struct {
-	        InstanceURL     string
-	        Username        string
-	        Password        string
-	        focused        int
-	        cursorPosition map[int]int
-	        err           error
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"sncli/internal/snow"
+)
+
+var (
+	appWidth = 60
+
+	subtleBlue  = lipgloss.Color("69")
+	subtleGray  = lipgloss.Color("241")
+	primaryBlue = lipgloss.Color("39")
+
+	focusedStyle = lipgloss.NewStyle().
+			Background(lipgloss.Color("236")).
+			Foreground(lipgloss.Color("252")).
+			Padding(1, 2).
+			MarginLeft(2).
+			Width(appWidth - 4)
+
+	blurredStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("242")).
+			Padding(1, 2).
+			MarginLeft(2).
+			Width(appWidth - 4)
+
+	focusIndicator = lipgloss.NewStyle().
+			Foreground(primaryBlue).
+			SetString("▎")
+
+	titleStyle = lipgloss.NewStyle().
+			Foreground(subtleBlue).
+			Bold(true).
+			Padding(1, 2).
+			MarginTop(1).
+			MarginBottom(2).
+			Width(appWidth).
+			Align(lipgloss.Center)
+
+	labelStyle = lipgloss.NewStyle().
+			Foreground(subtleGray).
+			PaddingLeft(2).
+			MarginBottom(1)
+
+	helpStyle = lipgloss.NewStyle().
+			Foreground(subtleGray).
+			Width(appWidth).
+			Align(lipgloss.Center).
+			MarginTop(2)
+
+	footerStyle = lipgloss.NewStyle().
+			Foreground(subtleGray).
+			Width(appWidth).
+			Align(lipgloss.Center).
+			PaddingTop(2).
+			BorderTop(true).
+			BorderStyle(lipgloss.Border{
+			Top: "─",
+		})
+
+	docStyle = lipgloss.NewStyle().
+			Padding(2).
+			Align(lipgloss.Center)
+)
+
+// Form fields, in focus order. The client credential fields are only shown
+// for OAuth.
+const (
+	fieldInstance = iota
+	fieldAuth
+	fieldUsername
+	fieldPassword
+	fieldClientID
+	fieldClientSecret
+)
+
+type LoginModel struct {
+	InstanceURL    string
+	AuthMethod     snow.AuthMethod
+	Username       string
+	Password       string
+	ClientID       string
+	ClientSecret   string
+	Submitted      bool
+	focused        int
+	cursorPosition map[int]int
+	err            error
+}
+
+func InitialModel() LoginModel {
+	return LoginModel{
+		AuthMethod:     snow.AuthBasic,
+		focused:        0,
+		cursorPosition: make(map[int]int),
+	}
+}
+
+func CreateProgram(m LoginModel) *tea.Program {
+	return tea.NewProgram(m)
+}
+
+func (m LoginModel) Init() tea.Cmd {
+	return nil
+}
+
+// fieldCount returns the number of visible fields for the selected auth
+// method.
+func (m LoginModel) fieldCount() int {
+	if m.AuthMethod == snow.AuthOAuth {
+		return fieldClientSecret + 1
+	}
+	return fieldPassword + 1
+}
+
+// input returns the text value backing a field.
+func (m *LoginModel) input(field int) *string {
+	switch field {
+	case fieldInstance:
+		return &m.InstanceURL
+	case fieldUsername:
+		return &m.Username
+	case fieldPassword:
+		return &m.Password
+	case fieldClientID:
+		return &m.ClientID
+	case fieldClientSecret:
+		return &m.ClientSecret
+	}
+	return nil
+}
+
+func (m *LoginModel) toggleAuth() {
+	if m.AuthMethod == snow.AuthOAuth {
+		m.AuthMethod = snow.AuthBasic
+	} else {
+		m.AuthMethod = snow.AuthOAuth
 	}
+}
+
+func (m LoginModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		n := m.fieldCount()
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			return m, tea.Quit
+
+		case tea.KeyTab:
+			m.focused = (m.focused + 1) % n
+			return m, nil
 
-	func InitialModel() LoginModel {
-	        return LoginModel{
-	                focused:        0,
-	                cursorPosition: make(map[int]int),
-	        }
+		case tea.KeyShiftTab:
+			m.focused = (m.focused - 1)
+			if m.focused < 0 {
+				m.focused = n - 1
+			}
+			return m, nil
+
+		case tea.KeyEnter:
+			if m.focused == n-1 && m.validate() {
+				m.Submitted = true
+				return m, tea.Quit
+			}
+			m.focused = (m.focused + 1) % n
+			return m, nil
+
+		case tea.KeyLeft, tea.KeyRight:
+			if m.focused == fieldAuth {
+				m.toggleAuth()
+				return m, nil
+			}
+			m.cursorPosition[m.focused], _ = m.updateCursor(*m.input(m.focused), msg)
+			return m, nil
+
+		case tea.KeySpace:
+			if m.focused == fieldAuth {
+				m.toggleAuth()
+				return m, nil
+			}
+		}
+
+		if value := m.input(m.focused); value != nil {
+			*value, _ = m.updateInput(*value, msg)
+			if pos, exists := m.cursorPosition[m.focused]; !exists || pos > len(*value) {
+				m.cursorPosition[m.focused] = len(*value)
+			}
+		}
 	}
 
-	func CreateProgram(m LoginModel) *tea.Program {
-	  return tea.NewProgram(m)
+	return m, nil
+}
+
+func (m *LoginModel) updateInput(current string, msg tea.KeyMsg) (string, tea.Cmd) {
+	pos := m.cursorPosition[m.focused]
+	switch msg.Type {
+	case tea.KeyBackspace:
+		if pos > 0 {
+			current = current[:pos-1] + current[pos:]
+			m.cursorPosition[m.focused]--
+		}
+	case tea.KeyDelete:
+		if pos < len(current) {
+			current = current[:pos] + current[pos+1:]
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		runes := string(msg.Runes)
+		if msg.Type == tea.KeySpace {
+			runes = " "
+		}
+		if pos == len(current) {
+			current += runes
+		} else {
+			current = current[:pos] + runes + current[pos:]
+		}
+		m.cursorPosition[m.focused] += len(runes)
 	}
+	return current, nil
+}
 
-	func (m LoginModel) Init() tea.Cmd {
-	  return nil
+func (m *LoginModel) updateCursor(current string, msg tea.KeyMsg) (int, tea.Cmd) {
+	pos := m.cursorPosition[m.focused]
+	switch msg.Type {
+	case tea.KeyLeft:
+		if pos > 0 {
+			pos--
+		}
+	case tea.KeyRight:
+		if pos < len(current) {
+			pos++
+		}
 	}
+	return pos, nil
+}
 
-	func (m LoginModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	        switch msg := msg.(type) {
-	        case tea.KeyMsg:
-	                switch msg.Type {
-	                case tea.KeyCtrlC, tea.KeyEsc:
-	                        return m, tea.Quit
-
-	                case tea.KeyTab:
-	                        m.focused = (m.focused + 1) % 3
-	                        return m, nil
-
-	                case tea.KeyShiftTab:
-	                        m.focused = (m.focused - 1)
-	                        if m.focused < 0 {
-	                                m.focused = 2
-	                        }
-	                        return m, nil
-
-	                case tea.KeyEnter:
-	                        if m.focused == 2 && m.validate() {
-	                                return m, tea.Quit
-	                        }
-	                        m.focused = (m.focused + 1) % 3
-	                        return m, nil
-
-	                case tea.KeyLeft, tea.KeyRight:
-	                        switch m.focused {
-	                        case 0:
-	                                m.cursorPosition[0], _ = m.updateCursor(m.InstanceURL, msg)
-	                        case 1:
-	                                m.cursorPosition[1], _ = m.updateCursor(m.Username, msg)
-	                        case 2:
-	                                m.cursorPosition[2], _ = m.updateCursor(m.Password, msg)
-	                        }
-	                        return m, nil
-	                }
-
-	                switch m.focused {
-	                case 0:
-	                        m.InstanceURL, _ = m.updateInput(m.InstanceURL, msg)
-	                        if pos, exists := m.cursorPosition[0]; !exists || pos > len(m.InstanceURL) {
-	                                m.cursorPosition[0] = len(m.InstanceURL)
-	                        }
-	                case 1:
-	                        m.Username, _ = m.updateInput(m.Username, msg)
-	                        if pos, exists := m.cursorPosition[1]; !exists || pos > len(m.Username) {
-	                                m.cursorPosition[1] = len(m.Username)
-	                        }
-	                case 2:
-	                        m.Password, _ = m.updateInput(m.Password, msg)
-	                        if pos, exists := m.cursorPosition[2]; !exists || pos > len(m.Password) {
-	                                m.cursorPosition[2] = len(m.Password)
-	                        }
-	                }
-	        }
-
-	        return m, nil
+func (m LoginModel) validate() bool {
+	if m.InstanceURL == "" || m.Username == "" || m.Password == "" {
+		return false
 	}
+	if m.AuthMethod == snow.AuthOAuth {
+		return m.ClientID != "" && m.ClientSecret != ""
+	}
+	return true
+}
 
-	func (m *LoginModel) updateInput(current string, msg tea.KeyMsg) (string, tea.Cmd) {
-	        pos := m.cursorPosition[m.focused]
-	        switch msg.Type {
-	        case tea.KeyBackspace:
-	                if pos > 0 {
-	                        current = current[:pos-1] + current[pos:]
-	                        m.cursorPosition[m.focused]--
-	                }
-	        case tea.KeyDelete:
-	                if pos < len(current) {
-	                        current = current[:pos] + current[pos+1:]
-	                }
-	        case tea.KeyRunes:
-	                if pos == len(current) {
-	                        current += string(msg.Runes)
-	                } else {
-	                        current = current[:pos] + string(msg.Runes) + current[pos:]
-	                }
-	                m.cursorPosition[m.focused]++
-	        }
-	        return current, nil
+// renderField writes one labelled input, with the cursor drawn when the field
+// is focused. Masked fields are shown as bullets.
+func (m LoginModel) renderField(s *strings.Builder, field int, label, value string, masked bool) {
+	style := blurredStyle
+	if m.focused == field {
+		s.WriteString(focusIndicator.Render())
+		style = focusedStyle
+	} else {
+		s.WriteString(" ")
 	}
 
-	func (m *LoginModel) updateCursor(current string, msg tea.KeyMsg) (int, tea.Cmd) {
-	        pos := m.cursorPosition[m.focused]
-	        switch msg.Type {
-	        case tea.KeyLeft:
-	                if pos > 0 {
-	                        pos--
-	                }
-	        case tea.KeyRight:
-	                if pos < len(current) {
-	                        pos++
-	                }
-	        }
-	        return pos, nil
+	if masked {
+		value = strings.Repeat("•", len(value))
+	}
+	if m.focused == field {
+		runes := []rune(value)
+		pos := m.cursorPosition[field]
+		if pos < len(runes) {
+			value = string(runes[:pos]) + "│" + string(runes[pos:])
+		} else {
+			value = value + "│"
+		}
 	}
+	s.WriteString(labelStyle.Render(label))
+	s.WriteString("\n" + style.Render(value) + "\n\n")
+}
+
+func (m LoginModel) View() string {
+	var s strings.Builder
+
+	title := titleStyle.Render("ServiceNow CLI Login")
+	s.WriteString(title + "\n")
+
+	s.WriteString(strings.Repeat("─", appWidth) + "\n\n")
 
-	func (m LoginModel) validate() bool {
-	  return m.InstanceURL != "" && m.Username != "" && m.Password != ""
+	m.renderField(&s, fieldInstance, "Instance (e.g., dev12345 or https://itsm.example.com):", m.InstanceURL, false)
+
+	// Auth method selector
+	authStyle := blurredStyle
+	if m.focused == fieldAuth {
+		s.WriteString(focusIndicator.Render())
+		authStyle = focusedStyle
+	} else {
+		s.WriteString(" ")
+	}
+	method := "Basic"
+	if m.AuthMethod == snow.AuthOAuth {
+		method = "OAuth 2.0"
 	}
+	s.WriteString(labelStyle.Render("Auth Method (←/→ to change):"))
+	s.WriteString("\n" + authStyle.Render("‹ "+method+" ›") + "\n\n")
 
-	func (m LoginModel) View() string {
-	        var s strings.Builder
-
-									title := titleStyle.Render("ServiceNow CLI Login")
-									s.WriteString(title + "\n")
-
-									s.WriteString(strings.Repeat("─", appWidth) + "\n\n")
-
-									// Instance URL field
-									instanceStyle := blurredStyle
-									if m.focused == 0 {
-									        s.WriteString(focusIndicator.Render())
-									        instanceStyle = focusedStyle
-									} else {
-									        s.WriteString(" ")
-									}
-	        instanceInput := m.InstanceURL
-	        if m.focused == 0 {
-	                pos := m.cursorPosition[0]
-	                if pos < len(instanceInput) {
-	                        instanceInput = instanceInput[:pos] + "│" + instanceInput[pos:]
-	                } else {
-	                        instanceInput = instanceInput + "│"
-	                }
-	        }
-									s.WriteString(labelStyle.Render("Instance Name (e.g., dev12345):"))
-									s.WriteString("\n" + instanceStyle.Render(instanceInput) + "\n\n")
-
-									// Username field
-									usernameStyle := blurredStyle
-									if m.focused == 1 {
-									        s.WriteString(focusIndicator.Render())
-									        usernameStyle = focusedStyle
-									} else {
-									        s.WriteString(" ")
-									}
-	        usernameInput := m.Username
-	        if m.focused == 1 {
-	                pos := m.cursorPosition[1]
-	                if pos < len(usernameInput) {
-	                        usernameInput = usernameInput[:pos] + "│" + usernameInput[pos:]
-	                } else {
-	                        usernameInput = usernameInput + "│"
-	                }
-	        }
-	        s.WriteString(labelStyle.Render("Username:"))
-	        s.WriteString("\n" + usernameStyle.Render(usernameInput) + "\n\n")
-
-	        // Password field
-									passwordStyle := blurredStyle
-									if m.focused == 2 {
-									        s.WriteString(focusIndicator.Render())
-									        passwordStyle = focusedStyle
-									} else {
-									        s.WriteString(" ")
-									}
-	        passwordInput := strings.Repeat("•", len(m.Password))
-	        if m.focused == 2 {
-	                pos := m.cursorPosition[2]
-	                if pos < len(passwordInput) {
-	                        passwordInput = passwordInput[:pos] + "│" + passwordInput[pos:]
-	                } else {
-	                        passwordInput = passwordInput + "│"
-	                }
-	        }
-	        s.WriteString(labelStyle.Render("Password:"))
-	        s.WriteString("\n" + passwordStyle.Render(passwordInput) + "\n\n")
-
-									s.WriteString("\n" + strings.Repeat("─", appWidth) + "\n")
-									help := helpStyle.Render("Tab: Navigate • Enter: Submit • Ctrl+c: Exit")
-									s.WriteString(help + "\n")
-
-									footer := footerStyle.Render("v1.0.0 • ServiceNow CLI")
-									s.WriteString(footer)
-
-	        return docStyle.Render(s.String())
+	m.renderField(&s, fieldUsername, "Username:", m.Username, false)
+	m.renderField(&s, fieldPassword, "Password:", m.Password, true)
+	if m.AuthMethod == snow.AuthOAuth {
+		m.renderField(&s, fieldClientID, "OAuth Client ID:", m.ClientID, false)
+		m.renderField(&s, fieldClientSecret, "OAuth Client Secret:", m.ClientSecret, true)
 	}
+
+	s.WriteString("\n" + strings.Repeat("─", appWidth) + "\n")
+	help := helpStyle.Render("Tab: Navigate • Enter: Submit • Ctrl+c: Exit")
+	s.WriteString(help + "\n")
+
+	footer := footerStyle.Render("v1.0.0 • ServiceNow CLI")
+	s.WriteString(footer)
+
+	return docStyle.Render(s.String())
+}
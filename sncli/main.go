@@ -1,12 +1,13 @@
-	package main
+package main
 
-	import (
-	  "log"
-	  "sncli/cmd"
-	)
+import (
+	"os"
 
-	func main() {
-	  if err := cmd.Execute(); err != nil {
-	    log.Fatal(err)
-	  }
+	"sncli/cmd"
+)
+
+func main() {
+	if err := cmd.Execute(); err != nil {
+		os.Exit(cmd.ExitCode(err))
 	}
+}